@@ -0,0 +1,152 @@
+// Command capture-server is a reference ScreenCaptureService backend for
+// development and integration tests. It stores what agents send under -data,
+// lists it at http://<http-addr>/records and serves uploaded images under
+// /images/.
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"capture-screen/internal/server"
+	"capture-screen/internal/storage"
+	pb "capture-screen/src/output"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+func main() {
+	grpcAddr := flag.String("grpc-addr", ":8443", "gRPC listen address")
+	httpAddr := flag.String("http-addr", ":8080", "HTTP listen address for the listing endpoint")
+	dataDir := flag.String("data", "capture-server-data", "directory records are stored in")
+	certFile := flag.String("tls-cert", "", "server certificate; serves plaintext when empty")
+	keyFile := flag.String("tls-key", "", "server private key")
+	clientCA := flag.String("client-ca", "", "CA bundle used to require and verify client certificates")
+	publicURL := flag.String("public-url", "", "base URL uploaded images are served under (default http://<http-addr>, with localhost for a wildcard host)")
+	retentionFlag := flag.String("retention", "keep-all", "retention policy for uploaded images: keep-all, keep-last:N or keep-for:<duration>")
+	flag.Parse()
+
+	if *publicURL == "" {
+		u, err := defaultPublicURL(*httpAddr)
+		if err != nil {
+			log.Fatalf("Invalid -http-addr %q: %v", *httpAddr, err)
+		}
+		*publicURL = u
+	}
+	retention, err := storage.ParseRetention(*retentionFlag)
+	if err != nil {
+		log.Fatalf("Invalid retention policy: %v", err)
+	}
+
+	store, err := server.OpenStore(*dataDir)
+	if err != nil {
+		log.Fatalf("Failed to open store: %v", err)
+	}
+	defer store.Close()
+	images, err := storage.NewLocalStore(filepath.Join(*dataDir, "images"), strings.TrimSuffix(*publicURL, "/")+"/images")
+	if err != nil {
+		log.Fatalf("Failed to open image store: %v", err)
+	}
+	srv := server.New(store, images, retention)
+
+	var opts []grpc.ServerOption
+	if *certFile != "" {
+		creds, err := serverCredentials(*certFile, *keyFile, *clientCA)
+		if err != nil {
+			log.Fatalf("Failed to load TLS credentials: %v", err)
+		}
+		opts = append(opts, grpc.Creds(creds))
+	} else {
+		log.Println("WARNING: serving gRPC without TLS")
+	}
+	grpcServer := grpc.NewServer(opts...)
+	pb.RegisterScreenCaptureServiceServer(grpcServer, srv)
+
+	lis, err := net.Listen("tcp", *grpcAddr)
+	if err != nil {
+		log.Fatalf("Failed to listen on %s: %v", *grpcAddr, err)
+	}
+	go func() {
+		log.Println("gRPC listening on", lis.Addr())
+		if err := grpcServer.Serve(lis); err != nil {
+			log.Fatalf("gRPC server stopped: %v", err)
+		}
+	}()
+
+	mux := http.NewServeMux()
+	mux.Handle("/", srv.Handler())
+	mux.Handle("/images/", http.StripPrefix("/images", images.Handler()))
+	httpServer := &http.Server{Addr: *httpAddr, Handler: mux}
+	go func() {
+		log.Println("HTTP listening on", *httpAddr)
+		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("HTTP server stopped: %v", err)
+		}
+	}()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	<-sigChan
+	log.Println("Shutting down gracefully...")
+	// Agents keep their command streams open, so don't wait on them forever.
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		grpcServer.Stop()
+	}
+	httpServer.Close()
+}
+
+// defaultPublicURL is the URL the HTTP listener on addr is reached at.
+func defaultPublicURL(addr string) (string, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", err
+	}
+	// A wildcard listen address can't be used in URLs.
+	if ip := net.ParseIP(host); host == "" || ip != nil && ip.IsUnspecified() {
+		host = "localhost"
+	}
+	return "http://" + net.JoinHostPort(host, port), nil
+}
+
+func serverCredentials(certFile, keyFile, clientCA string) (credentials.TransportCredentials, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+	}
+	if clientCA != "" {
+		pem, err := os.ReadFile(clientCA)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", clientCA)
+		}
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return credentials.NewTLS(cfg), nil
+}
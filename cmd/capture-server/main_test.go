@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+func TestDefaultPublicURL(t *testing.T) {
+	tests := []struct {
+		addr string
+		want string
+	}{
+		{":8080", "http://localhost:8080"},
+		{"0.0.0.0:8080", "http://localhost:8080"},
+		{"[::]:8080", "http://localhost:8080"},
+		{"127.0.0.1:18080", "http://127.0.0.1:18080"},
+		{"capture.test:80", "http://capture.test:80"},
+		{"[::1]:8080", "http://[::1]:8080"},
+	}
+	for _, tt := range tests {
+		got, err := defaultPublicURL(tt.addr)
+		if err != nil || got != tt.want {
+			t.Errorf("defaultPublicURL(%q) = %q, %v, want %q", tt.addr, got, err, tt.want)
+		}
+	}
+	if got, err := defaultPublicURL("8080"); err == nil {
+		t.Errorf("defaultPublicURL(%q) = %q, want an error", "8080", got)
+	}
+}
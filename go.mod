@@ -11,7 +11,9 @@ require (
 	github.com/gosimple/slug v1.14.0
 	github.com/joho/godotenv v1.5.1
 	github.com/kbinani/screenshot v0.0.0-20240820160931-a8a2c5d0e191
+	github.com/robfig/cron/v3 v3.0.1
 	github.com/shirou/gopsutil/v3 v3.24.5
+	golang.org/x/image v0.18.0
 	google.golang.org/grpc v1.68.0
 	google.golang.org/protobuf v1.34.2
 )
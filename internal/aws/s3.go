@@ -3,78 +3,217 @@ package aws
 import (
 	"bytes"
 	"capture-screen/internal/config"
+	"capture-screen/internal/storage"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 
 )
 
 type S3Service struct {
-    client *s3.Client
-    bucket string
+    client    *s3.Client
+    bucket    string
+    region    string
+
+    // endpoint and pathStyle are set for S3-compatible stores such as MinIO.
+    endpoint  *url.URL
+    pathStyle bool
+
+    // presign is set when captures should be shared through presigned GET
+    // URLs instead of public object URLs.
+    presign       *s3.PresignClient
+    presignExpiry time.Duration
 }
 
+// maxPresignExpiry is the longest lifetime SigV4 allows for a presigned URL.
+const maxPresignExpiry = 7 * 24 * time.Hour
+
+// deleteBatchSize is the most keys a single DeleteObjects call accepts.
+const deleteBatchSize = 1000
+
 func NewS3Service(ctx context.Context) (*S3Service, error) {
     b := config.GetEnv("S3_BUCKET_NAME")
 	accessKey := config.GetEnv("S3_ACCESS_KEY_ID")
 	secretKey := config.GetEnv("S3_SECRET_ACCESS_KEY")
 	region := config.GetEnv("S3_REGION")
+	pathStyle := config.GetEnvBool("S3_FORCE_PATH_STYLE", false)
+	var endpoint *url.URL
+	if raw := os.Getenv("S3_ENDPOINT"); raw != "" {
+		var err error
+		endpoint, err = url.Parse(raw)
+		if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+			return nil, fmt.Errorf("S3_ENDPOINT must be an http(s) URL, got %q", raw)
+		}
+	}
+	tlsConfig, err := s3TLSConfig()
+	if err != nil {
+		return nil, err
+	}
 	customResolver := awsconfig.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(accessKey, secretKey, ""))
+	httpClient := awsconfig.WithHTTPClient(awshttp.NewBuildableClient().WithTransportOptions(func(tr *http.Transport) {
+		tr.TLSClientConfig = tlsConfig
+	}))
 
-	cfg, err := awsconfig.LoadDefaultConfig(ctx, customResolver, awsconfig.WithRegion(region))
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, customResolver, httpClient, awsconfig.WithRegion(region))
 	if err != nil {
 		log.Fatalf("Unable to load SDK config, %v", err)
 	}
 	
-    client := s3.NewFromConfig(cfg)
-    return &S3Service{client: client, bucket: b}, nil
-}
-func (s *S3Service) UploadImage(ctx context.Context, imageBytes []byte, deviceName string) (string, error) {
-    
-	// Delete previous screenshots for this device
-	prefix := config.GetEnv("S3_FOLDER_NAME") + "/" + deviceName + "/"
-	listInput := &s3.ListObjectsV2Input{
-		Bucket: aws.String(s.bucket),
-		Prefix: aws.String(prefix),
+    client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if endpoint != nil {
+			o.BaseEndpoint = aws.String(endpoint.String())
+		}
+		o.UsePathStyle = pathStyle
+	})
+	service := &S3Service{
+		client:    client,
+		bucket:    b,
+		region:    region,
+		endpoint:  endpoint,
+		pathStyle: pathStyle,
 	}
-	
-	result, err := s.client.ListObjectsV2(ctx, listInput)
-	if err != nil {
-		return "", fmt.Errorf("failed to list objects: %w", err)
+	if config.GetEnvBool("S3_PRESIGN", false) {
+		expiry := config.GetEnvDuration("S3_PRESIGN_EXPIRY", time.Hour)
+		if expiry <= 0 || expiry > maxPresignExpiry {
+			return nil, fmt.Errorf("S3_PRESIGN_EXPIRY must be between 1s and %v, got %v", maxPresignExpiry, expiry)
+		}
+		service.presign = s3.NewPresignClient(client)
+		service.presignExpiry = expiry
 	}
+	return service, nil
+}
 
-	for _, obj := range result.Contents {
-		deleteInput := &s3.DeleteObjectInput{
-			Bucket: aws.String(s.bucket),
-			Key:    obj.Key,
+// s3TLSConfig builds the TLS settings for talking to the store. S3_CA_FILE
+// adds a PEM bundle to the system roots, S3_TLS_INSECURE disables
+// verification for local test setups.
+func s3TLSConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caFile := os.Getenv("S3_CA_FILE"); caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read S3_CA_FILE: %w", err)
 		}
-		
-		_, err = s.client.DeleteObject(ctx, deleteInput)
+		pool, err := x509.SystemCertPool()
 		if err != nil {
-			return "", fmt.Errorf("failed to delete object %s: %w", *obj.Key, err)
+			pool = x509.NewCertPool()
 		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", caFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if config.GetEnvBool("S3_TLS_INSECURE", false) {
+		log.Println("WARNING: S3 TLS certificate verification is disabled")
+		tlsConfig.InsecureSkipVerify = true
 	}
+	return tlsConfig, nil
+}
 
-	// Upload new screenshot
-	key := prefix + time.Now().Format("2006-01-02-15-04-05") + ".png"
-	input := &s3.PutObjectInput{
+// publicURL builds the unsigned URL of key on the configured endpoint, or on
+// the bucket's regional AWS host.
+func (s *S3Service) publicURL(key string) string {
+	base := s.endpoint
+	if base == nil {
+		base = &url.URL{Scheme: "https", Host: fmt.Sprintf("s3.%s.amazonaws.com", s.region)}
+	}
+	u := *base
+	if s.pathStyle {
+		u.Path = path.Join("/", u.Path, s.bucket, key)
+	} else {
+		u.Host = s.bucket + "." + u.Host
+		u.Path = path.Join("/", u.Path, key)
+	}
+	return u.String()
+}
+
+// URL returns the URL a capture is shared with: a presigned GET URL when
+// presigning is enabled, otherwise the public object URL.
+func (s *S3Service) URL(ctx context.Context, key string) (string, error) {
+	if s.presign == nil {
+		return s.publicURL(key), nil
+	}
+	req, err := s.presign.PresignGetObject(ctx, &s3.GetObjectInput{
 		Bucket: aws.String(s.bucket),
 		Key:    aws.String(key),
-		Body:   bytes.NewReader(imageBytes),
+	}, s3.WithPresignExpires(s.presignExpiry))
+	if err != nil {
+		return "", fmt.Errorf("failed to presign %s: %w", key, err)
+	}
+	return req.URL, nil
+}
+
+func (s *S3Service) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	input := &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String(contentType),
 	}
 
-	_, err = s.client.PutObject(ctx, input)
+	_, err := s.client.PutObject(ctx, input)
 	if err != nil {
-		return "", fmt.Errorf("failed to upload file: %w", err)
+		return fmt.Errorf("failed to upload file: %w", err)
+	}
+	return nil
+}
+
+// List pages through every object under prefix.
+func (s *S3Service) List(ctx context.Context, prefix string) ([]storage.Object, error) {
+	var objects []storage.Object
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list objects: %w", err)
+		}
+		for _, obj := range page.Contents {
+			objects = append(objects, storage.Object{
+				Key:     aws.ToString(obj.Key),
+				Size:    aws.ToInt64(obj.Size),
+				ModTime: aws.ToTime(obj.LastModified),
+			})
+		}
 	}
+	return objects, nil
+}
+
+// Delete removes keys with as few DeleteObjects calls as possible.
+func (s *S3Service) Delete(ctx context.Context, keys ...string) error {
+	for start := 0; start < len(keys); start += deleteBatchSize {
+		end := min(start+deleteBatchSize, len(keys))
+		objects := make([]types.ObjectIdentifier, 0, end-start)
+		for _, key := range keys[start:end] {
+			objects = append(objects, types.ObjectIdentifier{Key: aws.String(key)})
+		}
 
-	url := fmt.Sprintf("https://%s.s3.amazonaws.com/%s", s.bucket, key)
-	return url, nil
-    
+		out, err := s.client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(s.bucket),
+			Delete: &types.Delete{Objects: objects, Quiet: aws.Bool(true)},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete objects: %w", err)
+		}
+		if len(out.Errors) > 0 {
+			e := out.Errors[0]
+			return fmt.Errorf("failed to delete %d objects, first %s: %s", len(out.Errors), aws.ToString(e.Key), aws.ToString(e.Message))
+		}
+	}
+	return nil
 }
@@ -0,0 +1,147 @@
+package capture
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/gif"
+	"io"
+	"strings"
+	"time"
+)
+
+// Package is how the frames of a burst are bundled into one object.
+type Package string
+
+const (
+	// PackageGIF is an animated GIF, viewable straight from its URL.
+	PackageGIF Package = "gif"
+	// PackageZip is a zip of encoded frames with a manifest.json.
+	PackageZip Package = "zip"
+)
+
+func ParsePackage(s string) (Package, error) {
+	switch strings.ToLower(s) {
+	case "gif":
+		return PackageGIF, nil
+	case "zip":
+		return PackageZip, nil
+	default:
+		return "", fmt.Errorf("unknown burst package %q: want gif or zip", s)
+	}
+}
+
+func (p Package) Extension() string {
+	if p == PackageZip {
+		return ".zip"
+	}
+	return ".gif"
+}
+
+func (p Package) ContentType() string {
+	if p == PackageZip {
+		return "application/zip"
+	}
+	return "image/gif"
+}
+
+// Animation collects frames for an animated GIF. Frames are reduced to a
+// palette as they are added, which keeps a long burst small in memory.
+type Animation struct {
+	gif   gif.GIF
+	delay int
+}
+
+// NewAnimation starts a looping animation that shows each frame for delay.
+func NewAnimation(delay time.Duration) *Animation {
+	// GIF delays are in hundredths of a second, and browsers stretch
+	// anything below two to a tenth of a second.
+	return &Animation{delay: max(int(delay/(10*time.Millisecond)), 2)}
+}
+
+// Add appends a frame. Every frame must have the size of the first one.
+func (a *Animation) Add(img *image.RGBA) error {
+	if len(a.gif.Image) > 0 && img.Rect.Size() != a.gif.Image[0].Rect.Size() {
+		return fmt.Errorf("gif: frame size changed from %v to %v", a.gif.Image[0].Rect.Size(), img.Rect.Size())
+	}
+	a.gif.Image = append(a.gif.Image, webSafe(img))
+	a.gif.Delay = append(a.gif.Delay, a.delay)
+	return nil
+}
+
+func (a *Animation) Encode(w io.Writer) error {
+	if len(a.gif.Image) == 0 {
+		return fmt.Errorf("gif: no frames")
+	}
+	return gif.EncodeAll(w, &a.gif)
+}
+
+// webSafe maps img onto the 216-colour web-safe palette. The palette is a
+// 6x6x6 cube, so each pixel's index is computed directly instead of searched
+// for, which keeps up with a burst even at full resolution.
+func webSafe(img *image.RGBA) *image.Paletted {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	p := image.NewPaletted(image.Rect(0, 0, w, h), palette.WebSafe)
+	for y := 0; y < h; y++ {
+		row := img.Pix[y*img.Stride : y*img.Stride+4*w]
+		out := p.Pix[y*p.Stride : y*p.Stride+w]
+		for x := range out {
+			r, g, b := int(row[4*x]), int(row[4*x+1]), int(row[4*x+2])
+			out[x] = uint8(36*((r+25)/51) + 6*((g+25)/51) + (b+25)/51)
+		}
+	}
+	return p
+}
+
+// ArchiveEntry is one encoded image in a burst archive.
+type ArchiveEntry struct {
+	Name       string    `json:"name"`
+	Frame      int       `json:"frame"`
+	CapturedAt time.Time `json:"capturedAt"`
+	// X, Y, Width and Height are the captured area in virtual-screen
+	// coordinates, before any scaling.
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Data   []byte `json:"-"`
+}
+
+// Manifest describes a burst archive. It is stored as manifest.json next to
+// the frames.
+type Manifest struct {
+	Device   string         `json:"device"`
+	Format   Format         `json:"format"`
+	Frames   int            `json:"frames"`
+	Interval string         `json:"interval"`
+	Entries  []ArchiveEntry `json:"entries"`
+}
+
+// WriteArchive writes the manifest followed by every entry as a zip.
+// Captures are already compressed, so entries are stored as they are.
+func WriteArchive(w io.Writer, m Manifest) error {
+	zw := zip.NewWriter(w)
+	manifest, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	f, err := zw.Create("manifest.json")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(manifest); err != nil {
+		return err
+	}
+	for _, e := range m.Entries {
+		f, err := zw.CreateHeader(&zip.FileHeader{Name: e.Name, Method: zip.Store, Modified: e.CapturedAt})
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(e.Data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
@@ -0,0 +1,202 @@
+package capture
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"strconv"
+
+	"github.com/kbinani/screenshot"
+)
+
+// Display is an active monitor and its position on the virtual screen.
+type Display struct {
+	Index  int             `json:"index"`
+	Bounds image.Rectangle `json:"-"`
+}
+
+// Capturer grabs pixels from the screen. ScreenCapturer talks to the real
+// display server while FakeCapturer lets the capture path run headless.
+type Capturer interface {
+	Displays() ([]Display, error)
+	CaptureDisplay(index int) (*image.RGBA, error)
+	CaptureRect(rect image.Rectangle) (*image.RGBA, error)
+}
+
+type ScreenCapturer struct{}
+
+func NewScreenCapturer() *ScreenCapturer {
+	return &ScreenCapturer{}
+}
+
+func (c *ScreenCapturer) Displays() ([]Display, error) {
+	n := screenshot.NumActiveDisplays()
+	if n <= 0 {
+		return nil, fmt.Errorf("no active displays found")
+	}
+	displays := make([]Display, n)
+	for i := 0; i < n; i++ {
+		displays[i] = Display{Index: i, Bounds: screenshot.GetDisplayBounds(i)}
+	}
+	return displays, nil
+}
+
+func (c *ScreenCapturer) CaptureDisplay(index int) (*image.RGBA, error) {
+	if index < 0 || index >= screenshot.NumActiveDisplays() {
+		return nil, fmt.Errorf("display %d does not exist", index)
+	}
+	return screenshot.CaptureDisplay(index)
+}
+
+func (c *ScreenCapturer) CaptureRect(rect image.Rectangle) (*image.RGBA, error) {
+	return screenshot.CaptureRect(rect)
+}
+
+// New returns the capturer selected by backend: "screen" (default),
+// "synthetic" or "file". source is the image path used by the file backend.
+func New(backend, source string) (Capturer, error) {
+	switch backend {
+	case "", "screen":
+		return NewScreenCapturer(), nil
+	case "synthetic":
+		return NewSyntheticCapturer(image.Rect(0, 0, 1920, 1080)), nil
+	case "file":
+		if source == "" {
+			return nil, fmt.Errorf("file capture backend requires a source image")
+		}
+		return NewFileCapturer(source)
+	default:
+		return nil, fmt.Errorf("unknown capture backend %q", backend)
+	}
+}
+
+type Mode int
+
+const (
+	ModeSingle Mode = iota
+	ModeAll
+	ModeVirtual
+)
+
+// Selector picks which displays a capture covers: a single display by index,
+// every display as separate images, or all displays stitched together.
+type Selector struct {
+	Mode  Mode
+	Index int
+}
+
+func ParseSelector(s string) (Selector, error) {
+	switch s {
+	case "":
+		return Selector{}, nil
+	case "all":
+		return Selector{Mode: ModeAll}, nil
+	case "virtual":
+		return Selector{Mode: ModeVirtual}, nil
+	}
+	index, err := strconv.Atoi(s)
+	if err != nil || index < 0 {
+		return Selector{}, fmt.Errorf("invalid display selector %q: want an index, \"all\" or \"virtual\"", s)
+	}
+	return Selector{Index: index}, nil
+}
+
+// UnmarshalJSON accepts either a display index or one of the selector names.
+func (s *Selector) UnmarshalJSON(b []byte) error {
+	var raw interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	switch v := raw.(type) {
+	case nil:
+		*s = Selector{}
+		return nil
+	case float64:
+		if v != float64(int(v)) {
+			return fmt.Errorf("invalid display index %v", v)
+		}
+		sel, err := ParseSelector(strconv.Itoa(int(v)))
+		if err != nil {
+			return err
+		}
+		*s = sel
+		return nil
+	case string:
+		sel, err := ParseSelector(v)
+		if err != nil {
+			return err
+		}
+		*s = sel
+		return nil
+	default:
+		return fmt.Errorf("invalid display selector %s", b)
+	}
+}
+
+// Frame is a captured image and the displays it covers. Bounds is the area of
+// the virtual screen the image was taken from.
+type Frame struct {
+	Image    *image.RGBA
+	Bounds   image.Rectangle
+	Displays []Display
+}
+
+// Take captures the displays chosen by sel. ModeAll returns one frame per
+// display, the other modes return a single frame.
+func Take(c Capturer, sel Selector) ([]Frame, error) {
+	displays, err := c.Displays()
+	if err != nil {
+		return nil, err
+	}
+
+	switch sel.Mode {
+	case ModeAll:
+		frames := make([]Frame, 0, len(displays))
+		for _, d := range displays {
+			img, err := c.CaptureDisplay(d.Index)
+			if err != nil {
+				return nil, fmt.Errorf("display %d: %v", d.Index, err)
+			}
+			frames = append(frames, Frame{Image: img, Bounds: d.Bounds, Displays: []Display{d}})
+		}
+		return frames, nil
+	case ModeVirtual:
+		frame, err := stitch(c, displays)
+		if err != nil {
+			return nil, err
+		}
+		return []Frame{frame}, nil
+	default:
+		if sel.Index >= len(displays) {
+			return nil, fmt.Errorf("display %d does not exist, %d active displays", sel.Index, len(displays))
+		}
+		d := displays[sel.Index]
+		img, err := c.CaptureDisplay(d.Index)
+		if err != nil {
+			return nil, err
+		}
+		return []Frame{{Image: img, Bounds: d.Bounds, Displays: []Display{d}}}, nil
+	}
+}
+
+// stitch captures every display and draws it onto a canvas covering the
+// union of their bounds. Gaps between monitors stay black.
+func stitch(c Capturer, displays []Display) (Frame, error) {
+	var union image.Rectangle
+	for _, d := range displays {
+		union = union.Union(d.Bounds)
+	}
+
+	canvas := image.NewRGBA(image.Rect(0, 0, union.Dx(), union.Dy()))
+	draw.Draw(canvas, canvas.Bounds(), image.NewUniform(color.Black), image.Point{}, draw.Src)
+	for _, d := range displays {
+		img, err := c.CaptureDisplay(d.Index)
+		if err != nil {
+			return Frame{}, fmt.Errorf("display %d: %v", d.Index, err)
+		}
+		draw.Draw(canvas, d.Bounds.Sub(union.Min), img, img.Bounds().Min, draw.Src)
+	}
+	return Frame{Image: canvas, Bounds: union, Displays: displays}, nil
+}
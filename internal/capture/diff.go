@@ -0,0 +1,63 @@
+package capture
+
+import "image"
+
+const (
+	// fingerprintSize is the number of cells along each side of a
+	// fingerprint.
+	fingerprintSize = 32
+	// cellTolerance is how far a cell's brightness may drift before it
+	// counts as changed, which absorbs dithering and font smoothing.
+	cellTolerance = 8
+)
+
+// Fingerprint is a coarse grayscale grid of a frame, used to tell whether the
+// screen changed between two captures.
+type Fingerprint struct {
+	bounds image.Rectangle
+	cells  [fingerprintSize * fingerprintSize]uint8
+}
+
+// NewFingerprint averages the brightness of img over a fixed grid.
+func NewFingerprint(img *image.RGBA) Fingerprint {
+	f := Fingerprint{bounds: img.Rect}
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	if w == 0 || h == 0 {
+		return f
+	}
+
+	var sums, counts [fingerprintSize * fingerprintSize]uint64
+	for y := 0; y < h; y++ {
+		row := img.Pix[y*img.Stride : y*img.Stride+4*w]
+		cy := y * fingerprintSize / h * fingerprintSize
+		for x := 0; x < w; x++ {
+			r, g, b := uint64(row[4*x]), uint64(row[4*x+1]), uint64(row[4*x+2])
+			// ITU-R BT.601 luma in 16.16 fixed point.
+			c := cy + x*fingerprintSize/w
+			sums[c] += (19595*r + 38470*g + 7471*b + 1<<15) >> 16
+			counts[c]++
+		}
+	}
+	for i := range f.cells {
+		if counts[i] > 0 {
+			f.cells[i] = uint8(sums[i] / counts[i])
+		}
+	}
+	return f
+}
+
+// Changed returns the percentage of the grid that differs between f and
+// other. Frames of different sizes are entirely changed.
+func (f Fingerprint) Changed(other Fingerprint) float64 {
+	if f.bounds != other.bounds {
+		return 100
+	}
+	changed := 0
+	for i, c := range f.cells {
+		d := int(c) - int(other.cells[i])
+		if d > cellTolerance || d < -cellTolerance {
+			changed++
+		}
+	}
+	return float64(changed) * 100 / float64(len(f.cells))
+}
@@ -0,0 +1,104 @@
+package capture
+
+import (
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+
+	"capture-screen/internal/webp"
+)
+
+type Format string
+
+const (
+	FormatJPEG Format = "jpeg"
+	FormatPNG  Format = "png"
+	// FormatWebP is lossless WebP, usually much smaller than PNG for
+	// screenshots.
+	FormatWebP Format = "webp"
+)
+
+const DefaultJPEGQuality = 70
+
+// Encoding is the image format and, for JPEG, the quality captures are
+// encoded with.
+type Encoding struct {
+	Format  Format
+	Quality int
+}
+
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(s) {
+	case "jpeg", "jpg":
+		return FormatJPEG, nil
+	case "png":
+		return FormatPNG, nil
+	case "webp", "webp-lossless":
+		return FormatWebP, nil
+	default:
+		return "", fmt.Errorf("unknown image format %q: want jpeg, png or webp", s)
+	}
+}
+
+func NewEncoding(format string, quality int) (Encoding, error) {
+	f, err := ParseFormat(format)
+	if err != nil {
+		return Encoding{}, err
+	}
+	e := Encoding{Format: f, Quality: quality}
+	if e.Quality == 0 {
+		e.Quality = DefaultJPEGQuality
+	}
+	if e.Quality < 1 || e.Quality > 100 {
+		return Encoding{}, fmt.Errorf("jpeg quality %d out of range 1-100", quality)
+	}
+	return e, nil
+}
+
+// With overrides the format and quality with any non-zero values, as sent
+// in a capture command.
+func (e Encoding) With(format string, quality int) (Encoding, error) {
+	if format == "" {
+		format = string(e.Format)
+	}
+	if quality == 0 {
+		quality = e.Quality
+	}
+	return NewEncoding(format, quality)
+}
+
+func (e Encoding) Extension() string {
+	switch e.Format {
+	case FormatPNG:
+		return ".png"
+	case FormatWebP:
+		return ".webp"
+	default:
+		return ".jpg"
+	}
+}
+
+func (e Encoding) ContentType() string {
+	switch e.Format {
+	case FormatPNG:
+		return "image/png"
+	case FormatWebP:
+		return "image/webp"
+	default:
+		return "image/jpeg"
+	}
+}
+
+func (e Encoding) Encode(w io.Writer, img image.Image) error {
+	switch e.Format {
+	case FormatPNG:
+		return png.Encode(w, img)
+	case FormatWebP:
+		return webp.Encode(w, img)
+	default:
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: e.Quality})
+	}
+}
@@ -0,0 +1,100 @@
+package capture
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+)
+
+// FakeCapturer serves fixed images laid out like monitors on a virtual
+// screen. It is used on headless machines and in tests.
+type FakeCapturer struct {
+	displays []Display
+	frames   []image.Image
+}
+
+// NewSyntheticCapturer creates one display per bounds, each filled with a
+// gradient so captures of different regions can be told apart.
+func NewSyntheticCapturer(bounds ...image.Rectangle) *FakeCapturer {
+	c := &FakeCapturer{}
+	for i, b := range bounds {
+		img := image.NewRGBA(b)
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				img.SetRGBA(x, y, color.RGBA{
+					R: uint8(x - b.Min.X),
+					G: uint8(y - b.Min.Y),
+					B: uint8(i * 64),
+					A: 0xff,
+				})
+			}
+		}
+		c.displays = append(c.displays, Display{Index: i, Bounds: b})
+		c.frames = append(c.frames, img)
+	}
+	return c
+}
+
+// NewFileCapturer loads one image per path and places the displays side by
+// side, left to right, starting at the origin.
+func NewFileCapturer(paths ...string) (*FakeCapturer, error) {
+	c := &FakeCapturer{}
+	x := 0
+	for i, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("error opening capture source: %v", err)
+		}
+		img, _, err := image.Decode(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error decoding capture source %s: %v", path, err)
+		}
+		size := img.Bounds().Size()
+		b := image.Rect(x, 0, x+size.X, size.Y)
+		x += size.X
+
+		frame := image.NewRGBA(b)
+		draw.Draw(frame, b, img, img.Bounds().Min, draw.Src)
+		c.displays = append(c.displays, Display{Index: i, Bounds: b})
+		c.frames = append(c.frames, frame)
+	}
+	return c, nil
+}
+
+func (c *FakeCapturer) Displays() ([]Display, error) {
+	if len(c.displays) == 0 {
+		return nil, fmt.Errorf("no active displays found")
+	}
+	return append([]Display(nil), c.displays...), nil
+}
+
+func (c *FakeCapturer) CaptureDisplay(index int) (*image.RGBA, error) {
+	if index < 0 || index >= len(c.displays) {
+		return nil, fmt.Errorf("display %d does not exist", index)
+	}
+	return c.CaptureRect(c.displays[index].Bounds)
+}
+
+// CaptureRect copies the requested area from every display it overlaps.
+// Pixels outside all displays are left black, as with a real screen grab.
+func (c *FakeCapturer) CaptureRect(rect image.Rectangle) (*image.RGBA, error) {
+	if rect.Empty() {
+		return nil, fmt.Errorf("capture rectangle %v is empty", rect)
+	}
+	img := image.NewRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.Black), image.Point{}, draw.Src)
+	for i, d := range c.displays {
+		overlap := d.Bounds.Intersect(rect)
+		if overlap.Empty() {
+			continue
+		}
+		dst := overlap.Sub(rect.Min)
+		draw.Draw(img, dst, c.frames[i], overlap.Min, draw.Src)
+	}
+	return img, nil
+}
@@ -0,0 +1,63 @@
+package capture
+
+import (
+	"fmt"
+	"image"
+)
+
+// Region is a rectangle relative to the top-left corner of a display.
+type Region struct {
+	Display int `json:"display"`
+	X       int `json:"x"`
+	Y       int `json:"y"`
+	Width   int `json:"width"`
+	Height  int `json:"height"`
+}
+
+// RegionError reports a region that can't be captured on this machine.
+type RegionError struct {
+	Region Region
+	Reason string
+}
+
+func (e *RegionError) Error() string {
+	r := e.Region
+	return fmt.Sprintf("invalid region %dx%d+%d+%d on display %d: %s", r.Width, r.Height, r.X, r.Y, r.Display, e.Reason)
+}
+
+// Clamp converts the region into virtual-screen coordinates and trims it to
+// the display bounds. The origin must lie on the display; only the far edges
+// are clamped.
+func (r Region) Clamp(display image.Rectangle) (image.Rectangle, error) {
+	if r.Width <= 0 || r.Height <= 0 {
+		return image.Rectangle{}, &RegionError{Region: r, Reason: "width and height must be positive"}
+	}
+	size := display.Size()
+	if r.X < 0 || r.Y < 0 || r.X >= size.X || r.Y >= size.Y {
+		return image.Rectangle{}, &RegionError{Region: r, Reason: fmt.Sprintf("origin is outside the %dx%d display", size.X, size.Y)}
+	}
+	rect := image.Rect(r.X, r.Y, r.X+r.Width, r.Y+r.Height).Add(display.Min)
+	return rect.Intersect(display), nil
+}
+
+// TakeRegion captures a single rectangle of one display.
+func TakeRegion(c Capturer, r Region) (Frame, error) {
+	displays, err := c.Displays()
+	if err != nil {
+		return Frame{}, err
+	}
+	if r.Display < 0 || r.Display >= len(displays) {
+		return Frame{}, &RegionError{Region: r, Reason: fmt.Sprintf("display does not exist, %d active displays", len(displays))}
+	}
+
+	d := displays[r.Display]
+	rect, err := r.Clamp(d.Bounds)
+	if err != nil {
+		return Frame{}, err
+	}
+	img, err := c.CaptureRect(rect)
+	if err != nil {
+		return Frame{}, err
+	}
+	return Frame{Image: img, Bounds: rect, Displays: []Display{d}}, nil
+}
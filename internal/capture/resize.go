@@ -0,0 +1,62 @@
+package capture
+
+import (
+	"fmt"
+	"image"
+
+	"golang.org/x/image/draw"
+)
+
+// Sizing limits the size of uploaded captures. Zero values mean no limit and
+// no thumbnail.
+type Sizing struct {
+	MaxWidth  int
+	MaxHeight int
+	// Thumbnail is the longest edge of the thumbnail uploaded next to each
+	// capture.
+	Thumbnail int
+}
+
+// With overrides the limits with any non-zero values, as sent in a capture
+// command.
+func (s Sizing) With(maxWidth, maxHeight, thumbnail int) (Sizing, error) {
+	if maxWidth < 0 || maxHeight < 0 || thumbnail < 0 {
+		return Sizing{}, fmt.Errorf("image sizes must not be negative")
+	}
+	if maxWidth != 0 {
+		s.MaxWidth = maxWidth
+	}
+	if maxHeight != 0 {
+		s.MaxHeight = maxHeight
+	}
+	if thumbnail != 0 {
+		s.Thumbnail = thumbnail
+	}
+	return s, nil
+}
+
+// Fit scales img down to fit within maxWidth x maxHeight, keeping its aspect
+// ratio. A zero limit leaves that dimension unbounded; images are never
+// enlarged.
+func Fit(img *image.RGBA, maxWidth, maxHeight int) *image.RGBA {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	scale := 1.0
+	if maxWidth > 0 && w > maxWidth {
+		scale = float64(maxWidth) / float64(w)
+	}
+	if maxHeight > 0 && h > maxHeight {
+		if s := float64(maxHeight) / float64(h); s < scale {
+			scale = s
+		}
+	}
+	if scale == 1.0 {
+		return img
+	}
+
+	dw := max(1, int(float64(w)*scale+0.5))
+	dh := max(1, int(float64(h)*scale+0.5))
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+	return dst
+}
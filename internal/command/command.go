@@ -0,0 +1,210 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"capture-screen/internal/capture"
+	"capture-screen/internal/schedule"
+)
+
+// Version is the envelope schema version understood by this agent.
+const Version = 1
+
+type Type string
+
+const (
+	TypeCaptureScreen Type = "capture-screen"
+	TypePingDevice    Type = "ping-device"
+	TypeScanDevices   Type = "scan-devices"
+	TypeListProcesses Type = "list-processes"
+	TypeSetSchedule   Type = "set-schedule"
+	TypeCaptureBurst  Type = "capture-burst"
+)
+
+// Rejection codes reported when a payload can't be handled.
+const (
+	CodeMalformed          = "malformed"
+	CodeUnsupportedVersion = "unsupported_version"
+	CodeUnknownType        = "unknown_type"
+	CodeInvalidArgs        = "invalid_args"
+)
+
+// Envelope is a single command published to the agent. Legacy plain-string
+// payloads are converted into an Envelope with Legacy set.
+type Envelope struct {
+	Version  int             `json:"version"`
+	ID       string          `json:"id"`
+	Type     Type            `json:"type"`
+	Target   string          `json:"target,omitempty"`
+	IssuedAt time.Time       `json:"issuedAt"`
+	Args     json.RawMessage `json:"args,omitempty"`
+	ReplyTo  string          `json:"replyTo,omitempty"`
+	Legacy   bool            `json:"-"`
+}
+
+// CaptureArgs are the arguments of a capture-screen command. When Region is
+// set it selects the display itself and Display must be left unset. Format,
+// Quality and the size limits override the agent's configuration.
+//
+// With OnlyChanged the capture is compared against the previous OnlyChanged
+// capture of the same display or region, and is only uploaded when more than
+// ChangeThreshold percent of the screen changed.
+type CaptureArgs struct {
+	Display   capture.Selector `json:"display"`
+	Region    *capture.Region  `json:"region,omitempty"`
+	Format    string           `json:"format,omitempty"`
+	Quality   int              `json:"quality,omitempty"`
+	MaxWidth  int              `json:"maxWidth,omitempty"`
+	MaxHeight int              `json:"maxHeight,omitempty"`
+	Thumbnail int              `json:"thumbnail,omitempty"`
+
+	OnlyChanged     bool    `json:"onlyChanged,omitempty"`
+	ChangeThreshold float64 `json:"changeThreshold,omitempty"`
+}
+
+// ProcessArgs are the arguments of a list-processes command. SortBy is "cpu"
+// or "memory". Cmdline asks for command lines, which the agent may still
+// redact or withhold.
+type ProcessArgs struct {
+	Limit   int    `json:"limit,omitempty"`
+	SortBy  string `json:"sortBy,omitempty"`
+	Cmdline bool   `json:"cmdline,omitempty"`
+}
+
+// ScheduleArgs are the arguments of a set-schedule command. The schedule
+// replaces any schedule with the same ID, or is deleted when Remove is set.
+// Its args are CaptureArgs.
+type ScheduleArgs struct {
+	schedule.Schedule
+	Remove bool `json:"remove,omitempty"`
+}
+
+// BurstArgs are the arguments of a capture-burst command: Frames captures
+// taken Interval apart, packaged as an animated GIF or a zip of images with a
+// manifest. The capture arguments apply to every frame.
+type BurstArgs struct {
+	CaptureArgs
+	Frames   int    `json:"frames,omitempty"`
+	Interval string `json:"interval,omitempty"`
+	Package  string `json:"package,omitempty"`
+}
+
+// Rejection describes why a payload was not executed. ReplyTo keeps the
+// reply channel of an envelope that decoded but failed validation.
+type Rejection struct {
+	ID      string `json:"id,omitempty"`
+	Type    Type   `json:"type,omitempty"`
+	ReplyTo string `json:"replyTo,omitempty"`
+	Code    string `json:"code"`
+	Reason  string `json:"reason"`
+	Payload string `json:"payload,omitempty"`
+}
+
+func (r *Rejection) Error() string {
+	return fmt.Sprintf("command rejected (%s): %s", r.Code, r.Reason)
+}
+
+func (r *Rejection) JSON() string {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return r.Error()
+	}
+	return string(b)
+}
+
+func reject(env *Envelope, payload, code, format string, args ...interface{}) *Rejection {
+	r := &Rejection{
+		Code:    code,
+		Reason:  fmt.Sprintf(format, args...),
+		Payload: payload,
+	}
+	if env != nil {
+		r.ID = env.ID
+		r.Type = env.Type
+		r.ReplyTo = env.ReplyTo
+	}
+	return r
+}
+
+var knownTypes = map[Type]bool{
+	TypeCaptureScreen: true,
+	TypePingDevice:    true,
+	TypeScanDevices:   true,
+	TypeListProcesses: true,
+	TypeSetSchedule:   true,
+	TypeCaptureBurst:  true,
+}
+
+// Parse turns a raw Redis payload into an Envelope. JSON payloads are decoded
+// as versioned envelopes, anything else is treated as a legacy command string
+// such as "capture-screen-<slug>". Errors are always of type *Rejection.
+func Parse(payload string) (*Envelope, error) {
+	trimmed := strings.TrimSpace(payload)
+	if !strings.HasPrefix(trimmed, "{") {
+		return parseLegacy(trimmed)
+	}
+
+	var env Envelope
+	if err := json.Unmarshal([]byte(trimmed), &env); err != nil {
+		return nil, reject(nil, payload, CodeMalformed, "invalid JSON envelope: %v", err)
+	}
+	if env.Version == 0 {
+		return nil, reject(&env, payload, CodeMalformed, "missing envelope version")
+	}
+	if env.Version > Version {
+		return nil, reject(&env, payload, CodeUnsupportedVersion, "envelope version %d is newer than supported version %d", env.Version, Version)
+	}
+	if env.ID == "" {
+		return nil, reject(&env, payload, CodeMalformed, "missing command id")
+	}
+	if !knownTypes[env.Type] {
+		return nil, reject(&env, payload, CodeUnknownType, "unknown command type %q", env.Type)
+	}
+	return &env, nil
+}
+
+func parseLegacy(payload string) (*Envelope, error) {
+	env := &Envelope{Version: Version, Legacy: true, IssuedAt: time.Now()}
+	switch {
+	case payload == string(TypeScanDevices):
+		env.Type = TypeScanDevices
+	case strings.HasPrefix(payload, string(TypeCaptureScreen)+"-"):
+		env.Type = TypeCaptureScreen
+		env.Target = strings.TrimPrefix(payload, string(TypeCaptureScreen)+"-")
+	case strings.HasPrefix(payload, string(TypePingDevice)+"-"):
+		env.Type = TypePingDevice
+		env.Target = strings.TrimPrefix(payload, string(TypePingDevice)+"-")
+	case strings.HasPrefix(payload, string(TypeListProcesses)+"-"):
+		env.Type = TypeListProcesses
+		env.Target = strings.TrimPrefix(payload, string(TypeListProcesses)+"-")
+	default:
+		return nil, reject(nil, payload, CodeUnknownType, "unknown command %q", payload)
+	}
+	return env, nil
+}
+
+// TargetsDevice reports whether the command is addressed to the given device
+// slug. Commands without a target are broadcast to every device.
+func (e *Envelope) TargetsDevice(slug string) bool {
+	return e.Target == "" || e.Target == slug
+}
+
+// DecodeArgs unmarshals the command arguments into v. Missing arguments leave
+// v untouched.
+func (e *Envelope) DecodeArgs(v interface{}) error {
+	if len(e.Args) == 0 || string(e.Args) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(e.Args, v); err != nil {
+		return reject(e, string(e.Args), CodeInvalidArgs, "invalid arguments for %s: %v", e.Type, err)
+	}
+	return nil
+}
+
+// Reject builds a rejection for an already parsed envelope.
+func (e *Envelope) Reject(code, format string, args ...interface{}) *Rejection {
+	return reject(e, "", code, format, args...)
+}
@@ -0,0 +1,49 @@
+package command
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseRejection(t *testing.T) {
+	tests := []struct {
+		payload string
+		want    Rejection
+	}{
+		{
+			payload: `{"id":"c1","type":"ping-device","replyTo":"ctl"}`,
+			want:    Rejection{ID: "c1", Type: TypePingDevice, ReplyTo: "ctl", Code: CodeMalformed},
+		},
+		{
+			payload: `{"version":99,"id":"c2","type":"ping-device","replyTo":"ctl"}`,
+			want:    Rejection{ID: "c2", Type: TypePingDevice, ReplyTo: "ctl", Code: CodeUnsupportedVersion},
+		},
+		{
+			payload: `{"version":1,"type":"ping-device","replyTo":"ctl"}`,
+			want:    Rejection{Type: TypePingDevice, ReplyTo: "ctl", Code: CodeMalformed},
+		},
+		{
+			payload: `{"version":1,"id":"c3","type":"reboot","replyTo":"ctl"}`,
+			want:    Rejection{ID: "c3", Type: "reboot", ReplyTo: "ctl", Code: CodeUnknownType},
+		},
+		{
+			payload: `{"version":1,"id":"c4",`,
+			want:    Rejection{Code: CodeMalformed},
+		},
+		{
+			payload: "reboot-device",
+			want:    Rejection{Code: CodeUnknownType},
+		},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.payload)
+		var rej *Rejection
+		if !errors.As(err, &rej) {
+			t.Errorf("Parse(%s) error = %v, want a rejection", tt.payload, err)
+			continue
+		}
+		if rej.ID != tt.want.ID || rej.Type != tt.want.Type || rej.ReplyTo != tt.want.ReplyTo || rej.Code != tt.want.Code {
+			t.Errorf("Parse(%s) = %+v, want %+v", tt.payload, *rej, tt.want)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"capture-screen/internal/schedule"
+	"capture-screen/internal/sysinfo"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Error codes reported in command results.
+const (
+	CodeCaptureFailed  = "capture_failed"
+	CodeUploadFailed   = "upload_failed"
+	CodeDeliveryFailed = "delivery_failed"
+	CodeInternal       = "internal"
+)
+
+// Error attaches a result code to a failure so the controller can tell a
+// failed capture apart from a failed upload.
+type Error struct {
+	Code string
+	Err  error
+}
+
+func (e *Error) Error() string { return e.Err.Error() }
+
+func (e *Error) Unwrap() error { return e.Err }
+
+func Errorf(code, format string, args ...interface{}) error {
+	return &Error{Code: code, Err: fmt.Errorf(format, args...)}
+}
+
+// ErrorCode returns the result code carried by err, or CodeInternal.
+func ErrorCode(err error) string {
+	var cmdErr *Error
+	if errors.As(err, &cmdErr) {
+		return cmdErr.Code
+	}
+	var rej *Rejection
+	if errors.As(err, &rej) {
+		return rej.Code
+	}
+	return CodeInternal
+}
+
+type ReplyKind string
+
+const (
+	ReplyAck      ReplyKind = "ack"
+	ReplyResult   ReplyKind = "result"
+	ReplyRejected ReplyKind = "rejected"
+)
+
+// DisplayInfo describes a captured display and where its image was stored.
+type DisplayInfo struct {
+	Index        int    `json:"index"`
+	X            int    `json:"x"`
+	Y            int    `json:"y"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+	ImageURL     string `json:"imageUrl,omitempty"`
+	ThumbnailURL string `json:"thumbnailUrl,omitempty"`
+}
+
+// RegionInfo is the area actually captured for a region request, in
+// virtual-screen coordinates after clamping.
+type RegionInfo struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// Result is the outcome of an executed command.
+type Result struct {
+	Success      bool
+	ErrorCode    string
+	ErrorMessage string
+	Duration     time.Duration
+	ImageURL     string
+	ThumbnailURL string
+	Displays     []DisplayInfo
+	Region       *RegionInfo
+	Unchanged    bool
+	ChangePct    float64
+	Frames       int
+	Processes    []sysinfo.Process
+	Schedules    []schedule.Schedule
+}
+
+// Reply is the message published back to the controller.
+type Reply struct {
+	ID           string              `json:"id,omitempty"`
+	Kind         ReplyKind           `json:"kind"`
+	Type         Type                `json:"type,omitempty"`
+	Device       string              `json:"device"`
+	Timestamp    time.Time           `json:"timestamp"`
+	Success      bool                `json:"success"`
+	ErrorCode    string              `json:"errorCode,omitempty"`
+	ErrorMessage string              `json:"errorMessage,omitempty"`
+	DurationMs   int64               `json:"durationMs,omitempty"`
+	ImageURL     string              `json:"imageUrl,omitempty"`
+	ThumbnailURL string              `json:"thumbnailUrl,omitempty"`
+	Displays     []DisplayInfo       `json:"displays,omitempty"`
+	Region       *RegionInfo         `json:"region,omitempty"`
+	Unchanged    bool                `json:"unchanged,omitempty"`
+	ChangePct    float64             `json:"changePercent,omitempty"`
+	Frames       int                 `json:"frames,omitempty"`
+	Processes    []sysinfo.Process   `json:"processes,omitempty"`
+	Schedules    []schedule.Schedule `json:"schedules,omitempty"`
+}
+
+// Replier publishes acks and results either on Redis pub/sub channels or
+// appends them to a Redis Stream. A replier created with NewFuncReplier hands
+// them to a function instead.
+type Replier struct {
+	client    *redis.Client
+	device    string
+	useStream bool
+	send      SendFunc
+}
+
+// SendFunc delivers a reply along with its JSON encoding.
+type SendFunc func(ctx context.Context, reply Reply, payload []byte) error
+
+const streamMaxLen = 1000
+
+// NewReplier creates a replier for the given device slug. transport is either
+// "pubsub" or "stream".
+func NewReplier(client *redis.Client, device, transport string) (*Replier, error) {
+	switch transport {
+	case "", "pubsub":
+		return &Replier{client: client, device: device}, nil
+	case "stream":
+		return &Replier{client: client, device: device, useStream: true}, nil
+	default:
+		return nil, fmt.Errorf("unknown reply transport %q", transport)
+	}
+}
+
+// NewFuncReplier creates a replier that passes every reply to send, for
+// command transports other than Redis.
+func NewFuncReplier(device string, send SendFunc) *Replier {
+	return &Replier{device: device, send: send}
+}
+
+// Channel returns where replies for env are published. Commands may name their
+// own reply channel; otherwise JSON commands get a per-command channel and
+// legacy commands share the device channel. Streams are always per device.
+func (r *Replier) Channel(env *Envelope) string {
+	if env != nil && env.ReplyTo != "" {
+		return env.ReplyTo
+	}
+	if !r.useStream && env != nil && env.ID != "" && !env.Legacy {
+		return "command-reply-" + env.ID
+	}
+	return "command-replies-" + r.device
+}
+
+func (r *Replier) Ack(ctx context.Context, env *Envelope) error {
+	return r.publish(ctx, env, Reply{
+		Kind:    ReplyAck,
+		Success: true,
+	})
+}
+
+func (r *Replier) Result(ctx context.Context, env *Envelope, res Result) error {
+	return r.publish(ctx, env, Reply{
+		Kind:         ReplyResult,
+		Success:      res.Success,
+		ErrorCode:    res.ErrorCode,
+		ErrorMessage: res.ErrorMessage,
+		DurationMs:   res.Duration.Milliseconds(),
+		ImageURL:     res.ImageURL,
+		ThumbnailURL: res.ThumbnailURL,
+		Displays:     res.Displays,
+		Region:       res.Region,
+		Unchanged:    res.Unchanged,
+		ChangePct:    res.ChangePct,
+		Frames:       res.Frames,
+		Processes:    res.Processes,
+		Schedules:    res.Schedules,
+	})
+}
+
+// Reject publishes a rejection. env may be nil when the payload could not be
+// parsed at all.
+func (r *Replier) Reject(ctx context.Context, env *Envelope, rej *Rejection) error {
+	if env == nil && (rej.ID != "" || rej.ReplyTo != "") {
+		env = &Envelope{ID: rej.ID, Type: rej.Type, ReplyTo: rej.ReplyTo}
+	}
+	return r.publish(ctx, env, Reply{
+		Kind:         ReplyRejected,
+		ErrorCode:    rej.Code,
+		ErrorMessage: rej.Reason,
+	})
+}
+
+func (r *Replier) publish(ctx context.Context, env *Envelope, reply Reply) error {
+	reply.Device = r.device
+	reply.Timestamp = time.Now()
+	if env != nil {
+		reply.ID = env.ID
+		reply.Type = env.Type
+	}
+
+	payload, err := json.Marshal(reply)
+	if err != nil {
+		return fmt.Errorf("error marshaling reply: %v", err)
+	}
+
+	if r.send != nil {
+		return r.send(ctx, reply, payload)
+	}
+	channel := r.Channel(env)
+	if r.useStream {
+		return r.client.XAdd(ctx, &redis.XAddArgs{
+			Stream: channel,
+			MaxLen: streamMaxLen,
+			Approx: true,
+			Values: map[string]interface{}{
+				"id":    reply.ID,
+				"kind":  string(reply.Kind),
+				"reply": string(payload),
+			},
+		}).Err()
+	}
+	return r.client.Publish(ctx, channel, payload).Err()
+}
@@ -7,6 +7,9 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -50,3 +53,70 @@ func GetEnv(key string) string {
     }
     return value
 }
+
+func GetEnvDefault(key, fallback string) string {
+    value, exists := os.LookupEnv(key)
+    if !exists || value == "" {
+        return fallback
+    }
+    return value
+}
+
+func GetEnvInt(key string, fallback int) int {
+    value, exists := os.LookupEnv(key)
+    if !exists || value == "" {
+        return fallback
+    }
+    n, err := strconv.Atoi(value)
+    if err != nil {
+        log.Fatalf("Environment variable %s is not a number: %v", key, err)
+    }
+    return n
+}
+
+func GetEnvFloat(key string, fallback float64) float64 {
+    value, exists := os.LookupEnv(key)
+    if !exists || value == "" {
+        return fallback
+    }
+    f, err := strconv.ParseFloat(value, 64)
+    if err != nil {
+        log.Fatalf("Environment variable %s is not a number: %v", key, err)
+    }
+    return f
+}
+
+func GetEnvBool(key string, fallback bool) bool {
+    value, exists := os.LookupEnv(key)
+    if !exists || value == "" {
+        return fallback
+    }
+    b, err := strconv.ParseBool(value)
+    if err != nil {
+        log.Fatalf("Environment variable %s is not a boolean: %v", key, err)
+    }
+    return b
+}
+
+func GetEnvDuration(key string, fallback time.Duration) time.Duration {
+    value, exists := os.LookupEnv(key)
+    if !exists || value == "" {
+        return fallback
+    }
+    d, err := time.ParseDuration(value)
+    if err != nil {
+        log.Fatalf("Environment variable %s is not a duration: %v", key, err)
+    }
+    return d
+}
+
+// GetEnvList splits a comma separated variable, dropping empty entries.
+func GetEnvList(key string) []string {
+    var list []string
+    for _, item := range strings.Split(os.Getenv(key), ",") {
+        if item = strings.TrimSpace(item); item != "" {
+            list = append(list, item)
+        }
+    }
+    return list
+}
@@ -0,0 +1,110 @@
+package grpcclient
+
+import (
+	"context"
+	"log"
+	"time"
+
+	pb "capture-screen/src/output"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+)
+
+// Options tune the long-lived connection to the capture server.
+type Options struct {
+	// KeepaliveTime is how long the connection may sit idle before the
+	// client pings the server. Servers reject pings more frequent than
+	// their enforcement policy allows, which is five minutes by default.
+	KeepaliveTime    time.Duration
+	KeepaliveTimeout time.Duration
+	// MaxBackoff caps the exponential delay between reconnect attempts.
+	MaxBackoff time.Duration
+}
+
+var DefaultOptions = Options{
+	KeepaliveTime:    5 * time.Minute,
+	KeepaliveTimeout: 20 * time.Second,
+	MaxBackoff:       30 * time.Second,
+}
+
+// Client owns one gRPC connection for the lifetime of the agent. The
+// connection is opened once and re-established with exponential backoff
+// whenever it drops.
+type Client struct {
+	conn   *grpc.ClientConn
+	client pb.ScreenCaptureServiceClient
+	cancel context.CancelFunc
+	opts   Options
+}
+
+func New(target string, creds credentials.TransportCredentials, opts Options) (*Client, error) {
+	conn, err := grpc.NewClient(target,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithConnectParams(grpc.ConnectParams{
+			Backoff: backoff.Config{
+				BaseDelay:  time.Second,
+				Multiplier: 1.6,
+				Jitter:     0.2,
+				MaxDelay:   opts.MaxBackoff,
+			},
+			MinConnectTimeout: 10 * time.Second,
+		}),
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:    opts.KeepaliveTime,
+			Timeout: opts.KeepaliveTimeout,
+		}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &Client{
+		conn:   conn,
+		client: pb.NewScreenCaptureServiceClient(conn),
+		cancel: cancel,
+		opts:   opts,
+	}
+	conn.Connect()
+	go c.watch(ctx)
+	return c, nil
+}
+
+// watch logs connection state changes and wakes the connection up again
+// when it goes idle, so the next command doesn't pay for the handshake.
+func (c *Client) watch(ctx context.Context) {
+	state := c.conn.GetState()
+	for {
+		log.Printf("gRPC connection state: %s", state)
+		switch state {
+		case connectivity.Idle:
+			c.conn.Connect()
+		case connectivity.Shutdown:
+			return
+		}
+		if !c.conn.WaitForStateChange(ctx, state) {
+			return
+		}
+		state = c.conn.GetState()
+	}
+}
+
+// State reports the current connectivity state.
+func (c *Client) State() connectivity.State {
+	return c.conn.GetState()
+}
+
+// SendCapture waits for the connection to become ready, within ctx, before
+// sending.
+func (c *Client) SendCapture(ctx context.Context, req *pb.ScreenCaptureRequest) (*pb.ScreenCaptureResponse, error) {
+	return c.client.SendCapture(ctx, req, grpc.WaitForReady(true))
+}
+
+func (c *Client) Close() error {
+	c.cancel()
+	return c.conn.Close()
+}
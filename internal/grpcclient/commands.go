@@ -0,0 +1,92 @@
+package grpcclient
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	pb "capture-screen/src/output"
+
+	"google.golang.org/grpc"
+)
+
+// CommandStream receives commands over the CommandStream RPC, as an
+// alternative to subscribing to Redis.
+type CommandStream struct {
+	client *Client
+	hello  *pb.AgentHello
+
+	mu     sync.Mutex
+	stream pb.ScreenCaptureService_CommandStreamClient
+}
+
+func (c *Client) CommandStream(hello *pb.AgentHello) *CommandStream {
+	return &CommandStream{client: c, hello: hello}
+}
+
+// Run keeps the stream open until ctx is done and calls handle, in its own
+// goroutine, for every command payload. A broken stream is reopened with
+// exponential backoff.
+func (s *CommandStream) Run(ctx context.Context, handle func(payload string)) {
+	delay := time.Second
+	for {
+		opened := time.Now()
+		err := s.session(ctx, handle)
+		if ctx.Err() != nil {
+			return
+		}
+		// A stream that stayed up for a while starts the backoff over.
+		if time.Since(opened) > s.client.opts.MaxBackoff {
+			delay = time.Second
+		}
+		log.Printf("Command stream closed: %v, reopening in %v", err, delay)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, s.client.opts.MaxBackoff)
+	}
+}
+
+func (s *CommandStream) session(ctx context.Context, handle func(payload string)) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := s.client.client.CommandStream(ctx, grpc.WaitForReady(true))
+	if err != nil {
+		return err
+	}
+	if err := stream.Send(&pb.AgentMessage{Message: &pb.AgentMessage_Hello{Hello: s.hello}}); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.stream = stream
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.stream = nil
+		s.mu.Unlock()
+	}()
+	log.Println("Command stream registered as", s.hello.GetDeviceSlug())
+
+	for {
+		cmd, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		go handle(cmd.GetPayload())
+	}
+}
+
+// Reply sends a command reply on the open stream.
+func (s *CommandStream) Reply(reply *pb.CommandReply) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stream == nil {
+		return errors.New("command stream is not connected")
+	}
+	return s.stream.Send(&pb.AgentMessage{Message: &pb.AgentMessage_Reply{Reply: reply}})
+}
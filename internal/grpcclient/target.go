@@ -0,0 +1,86 @@
+package grpcclient
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// DefaultPort is used when a host target doesn't name a port.
+const DefaultPort = "8443"
+
+// ParseTarget turns a configured server address into a gRPC target. It
+// accepts:
+//
+//	host, host:port, [ipv6]:port
+//	dns:///host:port, dns://resolver/host:port
+//	unix:///abs/path, unix:rel/path, unix-abstract:name
+//
+// A leading "https://" or "http://" is dropped for compatibility with older
+// configurations. Host targets without a port get DefaultPort.
+func ParseTarget(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", fmt.Errorf("gRPC target is empty")
+	}
+	raw = strings.TrimPrefix(raw, "https://")
+	raw = strings.TrimPrefix(raw, "http://")
+
+	scheme, rest, hasScheme := strings.Cut(raw, ":")
+	if hasScheme {
+		switch scheme {
+		case "dns":
+			u, err := url.Parse(raw)
+			if err != nil {
+				return "", fmt.Errorf("invalid gRPC target %q: %v", raw, err)
+			}
+			endpoint := strings.TrimPrefix(u.Path, "/")
+			if u.Opaque != "" {
+				endpoint = u.Opaque
+			}
+			hostPort, err := withPort(endpoint)
+			if err != nil {
+				return "", fmt.Errorf("invalid gRPC target %q: %v", raw, err)
+			}
+			return "dns://" + u.Host + "/" + hostPort, nil
+		case "unix", "unix-abstract":
+			if strings.Trim(rest, "/") == "" {
+				return "", fmt.Errorf("invalid gRPC target %q: missing socket path", raw)
+			}
+			return raw, nil
+		}
+	}
+
+	hostPort, err := withPort(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid gRPC target %q: %v", raw, err)
+	}
+	return hostPort, nil
+}
+
+// withPort validates host[:port] and fills in DefaultPort.
+func withPort(s string) (string, error) {
+	if s == "" {
+		return "", fmt.Errorf("missing host")
+	}
+	if strings.ContainsAny(s, "/?# ") {
+		return "", fmt.Errorf("unexpected path or query in address")
+	}
+	host, port, err := net.SplitHostPort(s)
+	if err != nil {
+		// No port: a bare hostname or a bracketed/plain IPv6 address.
+		host, port = strings.TrimSuffix(strings.TrimPrefix(s, "["), "]"), DefaultPort
+		if strings.Contains(host, ":") && net.ParseIP(host) == nil {
+			return "", err
+		}
+	}
+	if host == "" {
+		return "", fmt.Errorf("missing host")
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return "", fmt.Errorf("invalid port %q", port)
+	}
+	return net.JoinHostPort(host, port), nil
+}
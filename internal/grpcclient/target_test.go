@@ -0,0 +1,66 @@
+package grpcclient
+
+import "testing"
+
+func TestParseTarget(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"capture.example.com", "capture.example.com:8443"},
+		{"capture.example.com:9000", "capture.example.com:9000"},
+		{"  capture.example.com:9000\n", "capture.example.com:9000"},
+		{"10.0.0.5", "10.0.0.5:8443"},
+		{"[::1]:9000", "[::1]:9000"},
+		{"[::1]", "[::1]:8443"},
+		{"::1", "[::1]:8443"},
+		{"2001:db8::1", "[2001:db8::1]:8443"},
+		{"dns:///capture.example.com", "dns:///capture.example.com:8443"},
+		{"dns:///capture.example.com:9000", "dns:///capture.example.com:9000"},
+		{"dns://8.8.8.8/capture.example.com", "dns://8.8.8.8/capture.example.com:8443"},
+		{"dns://8.8.8.8:53/capture.example.com:9000", "dns://8.8.8.8:53/capture.example.com:9000"},
+		{"unix:///var/run/capture.sock", "unix:///var/run/capture.sock"},
+		{"unix:run/capture.sock", "unix:run/capture.sock"},
+		{"unix-abstract:capture", "unix-abstract:capture"},
+		{"https://capture.example.com", "capture.example.com:8443"},
+		{"https://capture.example.com:9000", "capture.example.com:9000"},
+		{"http://[::1]:9000", "[::1]:9000"},
+	}
+	for _, tt := range tests {
+		got, err := ParseTarget(tt.in)
+		if err != nil {
+			t.Errorf("ParseTarget(%q) error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseTarget(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseTargetInvalid(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"   ",
+		":9000",
+		"capture.example.com:0",
+		"capture.example.com:65536",
+		"capture.example.com:grpc",
+		"capture.example.com/api",
+		"https://capture.example.com/api",
+		"capture.example.com:9000?tls=1",
+		"capture example.com",
+		"1:2:3",
+		"[::1]:",
+		"dns:///",
+		"dns:///capture.example.com:0",
+		"dns://8.8.8.8/capture.example.com/api",
+		"unix:",
+		"unix:///",
+		"unix-abstract:",
+	} {
+		if got, err := ParseTarget(in); err == nil {
+			t.Errorf("ParseTarget(%q) = %q, want an error", in, got)
+		}
+	}
+}
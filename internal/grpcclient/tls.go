@@ -0,0 +1,135 @@
+package grpcclient
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// Root CA sources for verifying the server certificate.
+const (
+	CASystem   = "system"
+	CAEmbedded = "embedded"
+	CAFile     = "file"
+)
+
+// TLSOptions control how the server certificate is verified.
+type TLSOptions struct {
+	// CASource is one of CASystem, CAEmbedded or CAFile.
+	CASource   string
+	CAFile     string
+	EmbeddedCA []byte
+	// ServerName overrides the name checked against the certificate, for
+	// servers reached through an address that isn't in their certificate.
+	ServerName string
+	// Pins are base64 SHA-256 hashes of SubjectPublicKeyInfo. When set, one
+	// certificate of the verified chain must match one of them; with
+	// InsecureDev only the server's own certificate is checked.
+	Pins []string
+	// InsecureDev disables certificate verification. Development only.
+	InsecureDev bool
+}
+
+// ParsePins splits a comma separated list of SPKI pins. Pins may carry a
+// "sha256/" prefix as in HPKP.
+func ParsePins(s string) ([]string, error) {
+	var pins []string
+	for _, p := range strings.Split(s, ",") {
+		p = strings.TrimPrefix(strings.TrimSpace(p), "sha256/")
+		if p == "" {
+			continue
+		}
+		if b, err := base64.StdEncoding.DecodeString(p); err != nil || len(b) != sha256.Size {
+			return nil, fmt.Errorf("invalid SPKI pin %q: want base64 encoded SHA-256", p)
+		}
+		pins = append(pins, p)
+	}
+	return pins, nil
+}
+
+// SPKIPin returns the pin for cert in the form accepted by ParsePins.
+func SPKIPin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// Config builds the client TLS configuration presenting cert.
+func (o TLSOptions) Config(cert tls.Certificate) (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		ServerName:   o.ServerName,
+	}
+
+	switch o.CASource {
+	case "", CASystem:
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			return nil, fmt.Errorf("error loading system root CAs: %v", err)
+		}
+		cfg.RootCAs = pool
+	case CAEmbedded:
+		if len(o.EmbeddedCA) == 0 {
+			return nil, errors.New("no CA bundle was embedded at build time")
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(o.EmbeddedCA) {
+			return nil, errors.New("no certificates found in embedded CA bundle")
+		}
+		cfg.RootCAs = pool
+	case CAFile:
+		pem, err := os.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading CA file: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", o.CAFile)
+		}
+		cfg.RootCAs = pool
+	default:
+		return nil, fmt.Errorf("unknown CA source %q", o.CASource)
+	}
+
+	if o.InsecureDev {
+		log.Println("!!! WARNING: gRPC server certificate verification is DISABLED !!!")
+		log.Println("!!! Any server can impersonate the capture server. Never use this in production. !!!")
+		cfg.InsecureSkipVerify = true
+	}
+
+	if len(o.Pins) > 0 {
+		pins := make(map[string]bool, len(o.Pins))
+		for _, p := range o.Pins {
+			pins[p] = true
+		}
+		// VerifyConnection runs after the chain has been verified. Pins are
+		// matched against the verified chains only: the server can send any
+		// certificate it likes, including a copy of a pinned one. In dev mode
+		// nothing is verified, so only the leaf, whose key the server must
+		// hold, can match.
+		cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) == 0 {
+				return errors.New("server sent no certificate")
+			}
+			chains := cs.VerifiedChains
+			if o.InsecureDev {
+				chains = [][]*x509.Certificate{cs.PeerCertificates[:1]}
+			}
+			for _, chain := range chains {
+				for _, c := range chain {
+					if pins[SPKIPin(c)] {
+						return nil
+					}
+				}
+			}
+			return errors.New("server certificate does not match any pinned public key")
+		}
+	}
+	return cfg, nil
+}
@@ -0,0 +1,113 @@
+package grpcclient
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// issue creates a certificate for name signed by parent, or self-signed
+// when parent is nil.
+func issue(t *testing.T, name string, ca bool, parent *tls.Certificate) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  ca,
+		BasicConstraintsValid: true,
+	}
+	if ca {
+		tmpl.KeyUsage = x509.KeyUsageCertSign
+	} else {
+		tmpl.DNSNames = []string{name}
+		tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	}
+	signer, signerKey := tmpl, any(key)
+	if parent != nil {
+		signer, signerKey = parent.Leaf, parent.PrivateKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+// handshake connects a client configured by opts to a server presenting
+// chain.
+func handshake(t *testing.T, opts TLSOptions, chain tls.Certificate) error {
+	t.Helper()
+	cfg, err := opts.Config(tls.Certificate{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.ServerName = "capture.test"
+	c, s := net.Pipe()
+	defer c.Close()
+	go func() {
+		defer s.Close()
+		tls.Server(s, &tls.Config{Certificates: []tls.Certificate{chain}}).Handshake()
+	}()
+	return tls.Client(c, cfg).Handshake()
+}
+
+func TestPins(t *testing.T) {
+	ca := issue(t, "Test CA", true, nil)
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Leaf.Raw}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	pinned := issue(t, "capture.test", false, &ca)
+	pinned.Certificate = append(pinned.Certificate, ca.Leaf.Raw)
+	// other is valid for the same name and tacks the public pinned
+	// certificate onto its chain.
+	other := issue(t, "capture.test", false, &ca)
+	other.Certificate = append(other.Certificate, pinned.Leaf.Raw, ca.Leaf.Raw)
+	selfSigned := issue(t, "capture.test", false, nil)
+	selfSigned.Certificate = append(selfSigned.Certificate, pinned.Leaf.Raw)
+
+	tests := []struct {
+		name   string
+		dev    bool
+		pin    *x509.Certificate
+		server tls.Certificate
+		ok     bool
+	}{
+		{"pinned leaf", false, pinned.Leaf, pinned, true},
+		{"pinned CA", false, ca.Leaf, other, true},
+		{"pinned certificate appended to another chain", false, pinned.Leaf, other, false},
+		{"dev mode pinned leaf", true, pinned.Leaf, pinned, true},
+		{"dev mode pinned CA", true, ca.Leaf, pinned, false},
+		{"dev mode pinned certificate appended", true, pinned.Leaf, selfSigned, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := TLSOptions{CASource: CAFile, CAFile: caFile, Pins: []string{SPKIPin(tt.pin)}, InsecureDev: tt.dev}
+			err := handshake(t, opts, tt.server)
+			if (err == nil) != tt.ok {
+				t.Errorf("handshake error = %v, want ok %v", err, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package grpcclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"sync"
+
+	"capture-screen/internal/storage"
+	pb "capture-screen/src/output"
+
+	_ "golang.org/x/image/webp"
+	"google.golang.org/grpc"
+)
+
+// chunkSize is the amount of image data sent per message.
+const chunkSize = 64 * 1024
+
+// UploadImage streams data to the server in chunks after its metadata. Size
+// and checksum are filled in from data.
+func (c *Client) UploadImage(ctx context.Context, meta *pb.ImageMetadata, data []byte) (*pb.UploadImageResponse, error) {
+	sum := sha256.Sum256(data)
+	meta.Size = int64(len(data))
+	meta.Sha256 = hex.EncodeToString(sum[:])
+
+	stream, err := c.client.UploadImage(ctx, grpc.WaitForReady(true))
+	if err != nil {
+		return nil, err
+	}
+	if err := stream.Send(&pb.ImageChunk{Chunk: &pb.ImageChunk_Metadata{Metadata: meta}}); err != nil {
+		return nil, err
+	}
+	for len(data) > 0 {
+		n := min(len(data), chunkSize)
+		if err := stream.Send(&pb.ImageChunk{Chunk: &pb.ImageChunk_Data{Data: data[:n]}}); err != nil {
+			return nil, err
+		}
+		data = data[n:]
+	}
+	return stream.CloseAndRecv()
+}
+
+// ImageStore uploads captures to the capture server, so the agent needs no
+// storage credentials of its own. Retention is up to the server: List
+// reports no objects and Delete does nothing, while pinned keys are sent
+// along with every upload so the server keeps them.
+type ImageStore struct {
+	client *Client
+	device string
+
+	mu     sync.Mutex
+	urls   map[string]string
+	recent []string
+	pinned map[string]bool
+}
+
+// recentURLs is how many of the latest uploads keep their URL. Captures
+// read their URLs right after uploading them, but commands and schedules
+// upload concurrently, so the URL of one upload must outlive those of a few
+// others.
+const recentURLs = 128
+
+var (
+	_ storage.ImageStore = (*ImageStore)(nil)
+	_ storage.Pinner     = (*ImageStore)(nil)
+)
+
+func NewImageStore(client *Client, device string) *ImageStore {
+	return &ImageStore{client: client, device: device, urls: make(map[string]string), pinned: make(map[string]bool)}
+}
+
+func (s *ImageStore) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	meta := &pb.ImageMetadata{
+		Key:         key,
+		DeviceName:  s.device,
+		ContentType: contentType,
+		Format:      formatOf(contentType),
+	}
+	if cfg, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+		meta.Width = int32(cfg.Width)
+		meta.Height = int32(cfg.Height)
+	}
+	s.mu.Lock()
+	for k := range s.pinned {
+		meta.Keep = append(meta.Keep, k)
+	}
+	s.mu.Unlock()
+
+	res, err := s.client.UploadImage(ctx, meta, data)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.urls[key] = res.GetUrl()
+	s.recent = append(s.recent, key)
+	if len(s.recent) > recentURLs {
+		old := s.recent[0]
+		s.recent = s.recent[1:]
+		if !s.pinned[old] && !s.isRecent(old) {
+			delete(s.urls, old)
+		}
+	}
+	return nil
+}
+
+func (s *ImageStore) isRecent(key string) bool {
+	for _, k := range s.recent {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *ImageStore) Pin(keys ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, k := range keys {
+		s.pinned[k] = true
+	}
+}
+
+func (s *ImageStore) Unpin(keys ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, k := range keys {
+		delete(s.pinned, k)
+		if !s.isRecent(k) {
+			delete(s.urls, k)
+		}
+	}
+}
+
+// formats names the format of everything the agent uploads. Archives don't
+// have an image content type to take the name from.
+var formats = map[string]string{
+	"image/jpeg":      "jpeg",
+	"image/png":       "png",
+	"image/webp":      "webp",
+	"image/gif":       "gif",
+	"application/zip": "zip",
+}
+
+func formatOf(contentType string) string {
+	if f, ok := formats[contentType]; ok {
+		return f
+	}
+	return contentType
+}
+
+func (s *ImageStore) Delete(ctx context.Context, keys ...string) error {
+	return nil
+}
+
+func (s *ImageStore) List(ctx context.Context, prefix string) ([]storage.Object, error) {
+	return nil, nil
+}
+
+// URL returns the URL the server reported when key was uploaded. It is
+// known for the latest uploads and for pinned keys.
+func (s *ImageStore) URL(ctx context.Context, key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	url, ok := s.urls[key]
+	if !ok {
+		return "", fmt.Errorf("no upload recorded for %s", key)
+	}
+	return url, nil
+}
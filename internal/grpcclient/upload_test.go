@@ -0,0 +1,102 @@
+package grpcclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"testing"
+
+	pb "capture-screen/src/output"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// uploadServer answers every upload with a URL naming its key and records
+// the keys it was asked to keep.
+type uploadServer struct {
+	pb.UnimplementedScreenCaptureServiceServer
+
+	mu   sync.Mutex
+	keep []string
+}
+
+func (s *uploadServer) UploadImage(stream pb.ScreenCaptureService_UploadImageServer) error {
+	var meta *pb.ImageMetadata
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if m := chunk.GetMetadata(); m != nil {
+			meta = m
+		}
+	}
+	s.mu.Lock()
+	s.keep = meta.GetKeep()
+	s.mu.Unlock()
+	return stream.SendAndClose(&pb.UploadImageResponse{Url: "test://" + meta.GetKey()})
+}
+
+func newTestStore(t *testing.T) (*ImageStore, *uploadServer) {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &uploadServer{}
+	gs := grpc.NewServer()
+	pb.RegisterScreenCaptureServiceServer(gs, srv)
+	go gs.Serve(lis)
+	t.Cleanup(gs.Stop)
+
+	client, err := New(lis.Addr().String(), insecure.NewCredentials(), DefaultOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return NewImageStore(client, "dev"), srv
+}
+
+func TestImageStoreURL(t *testing.T) {
+	store, srv := newTestStore(t)
+	ctx := context.Background()
+	put := func(key string) {
+		t.Helper()
+		if err := store.Put(ctx, key, []byte("data"), "image/jpeg"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	put("dev/pinned.jpg")
+	store.Pin("dev/pinned.jpg")
+	put("dev/first.jpg")
+	// Uploads of other captures running at the same time.
+	for i := 0; i < recentURLs-1; i++ {
+		put(fmt.Sprintf("dev/%d.jpg", i))
+	}
+	if url, err := store.URL(ctx, "dev/first.jpg"); err != nil || url != "test://dev/first.jpg" {
+		t.Errorf("URL(first) = %q, %v after %d other uploads", url, err, recentURLs-1)
+	}
+	if got := srv.keep; len(got) != 1 || got[0] != "dev/pinned.jpg" {
+		t.Errorf("keep = %v, want [dev/pinned.jpg]", got)
+	}
+
+	put("dev/last.jpg")
+	if _, err := store.URL(ctx, "dev/first.jpg"); err == nil {
+		t.Errorf("URL(first) still known after %d other uploads", recentURLs)
+	}
+	if url, err := store.URL(ctx, "dev/pinned.jpg"); err != nil || url != "test://dev/pinned.jpg" {
+		t.Errorf("URL(pinned) = %q, %v", url, err)
+	}
+
+	store.Unpin("dev/pinned.jpg")
+	if _, err := store.URL(ctx, "dev/pinned.jpg"); err == nil {
+		t.Error("URL(pinned) still known after it was unpinned")
+	}
+}
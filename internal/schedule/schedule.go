@@ -0,0 +1,268 @@
+// Package schedule runs captures on cron expressions or fixed intervals and
+// keeps remotely pushed schedules across restarts.
+package schedule
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// LocalID names the schedule configured on the agent itself.
+const LocalID = "local"
+
+const (
+	minInterval = 10 * time.Second
+	maxJitter   = time.Hour
+)
+
+// Schedule fires a capture either on a cron expression ("*/15 * * * *",
+// "@hourly") or every fixed interval. Each run is delayed by a random amount
+// up to Jitter so a fleet of agents doesn't capture in lockstep; Jitter must
+// be shorter than the time between runs. Args are the capture-screen
+// arguments used for every run.
+type Schedule struct {
+	ID     string          `json:"id"`
+	Cron   string          `json:"cron,omitempty"`
+	Every  string          `json:"every,omitempty"`
+	Jitter string          `json:"jitter,omitempty"`
+	Args   json.RawMessage `json:"args,omitempty"`
+}
+
+// Validate checks the timing fields without scheduling anything.
+func (s Schedule) Validate() error {
+	_, err := s.spec()
+	return err
+}
+
+// spec parses the schedule into something cron can run.
+func (s Schedule) spec() (cron.Schedule, error) {
+	if s.ID == "" {
+		return nil, errors.New("schedule id is required")
+	}
+	var sched cron.Schedule
+	switch {
+	case s.Cron != "" && s.Every != "":
+		return nil, errors.New("set either cron or every, not both")
+	case s.Cron != "":
+		var err error
+		if sched, err = cron.ParseStandard(s.Cron); err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %v", s.Cron, err)
+		}
+	case s.Every != "":
+		every, err := time.ParseDuration(s.Every)
+		if err != nil {
+			return nil, fmt.Errorf("invalid interval %q: %v", s.Every, err)
+		}
+		if every < minInterval {
+			return nil, fmt.Errorf("interval must be at least %v", minInterval)
+		}
+		sched = cron.Every(every)
+	default:
+		return nil, errors.New("one of cron or every is required")
+	}
+
+	if s.Jitter == "" {
+		return sched, nil
+	}
+	jitter, err := time.ParseDuration(s.Jitter)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jitter %q: %v", s.Jitter, err)
+	}
+	if jitter < 0 || jitter > maxJitter {
+		return nil, fmt.Errorf("jitter must be between 0 and %v", maxJitter)
+	}
+	if gap := shortestGap(sched, time.Now()); jitter >= gap {
+		return nil, fmt.Errorf("jitter must be shorter than the %v between runs", gap)
+	}
+	return &jittered{Schedule: sched, jitter: jitter}, nil
+}
+
+// shortestGap returns the shortest time between two runs of sched during the
+// week after from, which covers every cron expression whose runs are closer
+// together than maxJitter.
+func shortestGap(sched cron.Schedule, from time.Time) time.Duration {
+	if every, ok := sched.(cron.ConstantDelaySchedule); ok {
+		return every.Delay
+	}
+	gap := time.Duration(1<<63 - 1)
+	end := from.Add(7 * 24 * time.Hour)
+	prev := sched.Next(from)
+	for !prev.IsZero() && prev.Before(end) {
+		next := sched.Next(prev)
+		if next.IsZero() {
+			break
+		}
+		gap = min(gap, next.Sub(prev))
+		prev = next
+	}
+	return gap
+}
+
+// jittered delays every run by a random amount up to jitter. cron asks for
+// the next run from the time the previous one fired, jitter included, so the
+// schedule continues from the un-jittered time instead. Otherwise the jitter
+// would add up and every interval would stretch by half of it on average.
+type jittered struct {
+	cron.Schedule
+	jitter time.Duration
+
+	mu   sync.Mutex
+	base time.Time
+}
+
+// jitterSlack allows for the scheduler waking up slightly after a run was
+// due.
+const jitterSlack = time.Second
+
+func (j *jittered) Next(t time.Time) time.Time {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	from := t
+	// A t further from the last run than jitter explains, such as after a
+	// restart or a suspended machine, starts afresh. So does one past the
+	// run after base, so a run is never scheduled before t.
+	if !j.base.IsZero() && !t.Before(j.base) && t.Sub(j.base) <= j.jitter+jitterSlack && j.Schedule.Next(j.base).After(t) {
+		from = j.base
+	}
+	j.base = j.Schedule.Next(from)
+	return j.base.Add(time.Duration(rand.Int63n(int64(j.jitter) + 1)))
+}
+
+// Scheduler runs schedules and saves the remote ones to a file.
+type Scheduler struct {
+	path string
+	run  func(Schedule)
+	cron *cron.Cron
+
+	mu        sync.Mutex
+	schedules map[string]Schedule
+	entries   map[string]cron.EntryID
+}
+
+// New creates a scheduler that calls run for every firing and persists
+// schedules to path.
+func New(path string, run func(Schedule)) *Scheduler {
+	return &Scheduler{
+		path: path,
+		run:  run,
+		// A capture still running when its schedule fires again is not
+		// started twice.
+		cron:      cron.New(cron.WithChain(cron.SkipIfStillRunning(cron.DefaultLogger))),
+		schedules: make(map[string]Schedule),
+		entries:   make(map[string]cron.EntryID),
+	}
+}
+
+// Load restores the schedules saved by a previous run. A missing file is
+// not an error.
+func (s *Scheduler) Load() error {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var saved []Schedule
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return fmt.Errorf("error parsing %s: %v", s.path, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sched := range saved {
+		if err := s.add(sched); err != nil {
+			log.Printf("Skipping saved schedule %q: %v", sched.ID, err)
+		}
+	}
+	return nil
+}
+
+// Set adds or replaces a schedule. Schedules other than LocalID are saved.
+func (s *Scheduler) Set(sched Schedule) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.add(sched); err != nil {
+		return err
+	}
+	if sched.ID == LocalID {
+		return nil
+	}
+	return s.save()
+}
+
+// Remove deletes a schedule. Removing an unknown schedule is not an error.
+func (s *Scheduler) Remove(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if entry, ok := s.entries[id]; ok {
+		s.cron.Remove(entry)
+		delete(s.entries, id)
+		delete(s.schedules, id)
+	}
+	return s.save()
+}
+
+// List returns the active schedules ordered by ID.
+func (s *Scheduler) List() []Schedule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Schedule, 0, len(s.schedules))
+	for _, sched := range s.schedules {
+		out = append(out, sched)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+func (s *Scheduler) Start() { s.cron.Start() }
+
+// Stop stops firing schedules and waits for running captures to finish.
+func (s *Scheduler) Stop() { <-s.cron.Stop().Done() }
+
+func (s *Scheduler) add(sched Schedule) error {
+	spec, err := sched.spec()
+	if err != nil {
+		return err
+	}
+	if entry, ok := s.entries[sched.ID]; ok {
+		s.cron.Remove(entry)
+	}
+	s.entries[sched.ID] = s.cron.Schedule(spec, cron.FuncJob(func() { s.run(sched) }))
+	s.schedules[sched.ID] = sched
+	return nil
+}
+
+// save writes the remote schedules through a temporary file so a crash
+// never leaves a truncated file behind.
+func (s *Scheduler) save() error {
+	saved := []Schedule{}
+	for _, sched := range s.schedules {
+		if sched.ID != LocalID {
+			saved = append(saved, sched)
+		}
+	}
+	sort.Slice(saved, func(i, j int) bool { return saved[i].ID < saved[j].ID })
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
@@ -0,0 +1,90 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+func TestJitterDoesNotAccumulate(t *testing.T) {
+	tests := []struct {
+		name   string
+		sched  Schedule
+		period time.Duration
+	}{
+		{"every", Schedule{ID: "every", Every: "10m", Jitter: "9m"}, 10 * time.Minute},
+		{"cron", Schedule{ID: "cron", Cron: "*/15 * * * *", Jitter: "5m"}, 15 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, err := tt.sched.spec()
+			if err != nil {
+				t.Fatal(err)
+			}
+			jitter, _ := time.ParseDuration(tt.sched.Jitter)
+
+			start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+			next := start
+			for i := 1; i <= 500; i++ {
+				// cron asks for the next run at the time the previous one
+				// fired.
+				next = spec.Next(next)
+				base := start.Add(time.Duration(i) * tt.period)
+				if next.Before(base) || next.After(base.Add(jitter)) {
+					t.Fatalf("run %d at %v, want between %v and %v", i, next, base, base.Add(jitter))
+				}
+			}
+		})
+	}
+}
+
+func TestJitterRestartsAfterGap(t *testing.T) {
+	spec, err := Schedule{ID: "gap", Every: "10m", Jitter: "1m"}.spec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	spec.Next(start)
+
+	// The machine was suspended for a day; the schedule continues from now.
+	resumed := start.Add(24 * time.Hour)
+	next := spec.Next(resumed)
+	if want := resumed.Add(10 * time.Minute); next.Before(want) || next.After(want.Add(time.Minute)) {
+		t.Fatalf("next run at %v, want between %v and %v", next, want, want.Add(time.Minute))
+	}
+}
+
+func TestJitterShorterThanRuns(t *testing.T) {
+	tests := []struct {
+		sched Schedule
+		ok    bool
+	}{
+		{Schedule{ID: "a", Every: "10m", Jitter: "9m59s"}, true},
+		{Schedule{ID: "b", Every: "10m", Jitter: "10m"}, false},
+		{Schedule{ID: "c", Every: "10s", Jitter: "1h"}, false},
+		{Schedule{ID: "d", Cron: "*/15 * * * *", Jitter: "14m"}, true},
+		{Schedule{ID: "e", Cron: "* * * * *", Jitter: "1m"}, false},
+		// Two runs a minute apart once an hour.
+		{Schedule{ID: "f", Cron: "0,1 * * * *", Jitter: "5m"}, false},
+		{Schedule{ID: "g", Cron: "@daily", Jitter: "1h"}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.sched.Validate(); (err == nil) != tt.ok {
+			t.Errorf("Validate(%+v) = %v, want ok %v", tt.sched, err, tt.ok)
+		}
+	}
+}
+
+func TestNextNeverBeforeT(t *testing.T) {
+	// Validation rules this out; Next copes regardless.
+	j := &jittered{Schedule: cron.Every(10 * time.Second), jitter: time.Hour}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 50; i++ {
+		next := j.Next(now)
+		if !next.After(now) {
+			t.Fatalf("run %d at %v, not after %v", i, next, now)
+		}
+		now = next
+	}
+}
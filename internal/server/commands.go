@@ -0,0 +1,157 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	pb "capture-screen/src/output"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrNotConnected is returned when a command is sent to a device without an
+// open command stream.
+var ErrNotConnected = errors.New("device is not connected")
+
+// maxCommandSize bounds command payloads posted over HTTP.
+const maxCommandSize = 64 * 1024
+
+// session is an agent's open command stream.
+type session struct {
+	hello       *pb.AgentHello
+	connectedAt time.Time
+
+	mu     sync.Mutex
+	stream pb.ScreenCaptureService_CommandStreamServer
+}
+
+func (c *session) send(payload string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stream.Send(&pb.AgentCommand{Payload: payload})
+}
+
+// DeviceInfo describes a connected agent.
+type DeviceInfo struct {
+	DeviceName  string    `json:"deviceName"`
+	DeviceSlug  string    `json:"deviceSlug"`
+	OSName      string    `json:"osName"`
+	ConnectedAt time.Time `json:"connectedAt"`
+}
+
+func (s *Server) CommandStream(stream pb.ScreenCaptureService_CommandStreamServer) error {
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	hello := first.GetHello()
+	if hello == nil || hello.GetDeviceSlug() == "" {
+		return status.Error(codes.InvalidArgument, "the first message must be a hello with deviceSlug")
+	}
+
+	sess := &session{hello: hello, connectedAt: time.Now().UTC(), stream: stream}
+	s.mu.Lock()
+	s.sessions[hello.GetDeviceSlug()] = sess
+	s.mu.Unlock()
+	log.Printf("Device %s connected", hello.GetDeviceSlug())
+	defer func() {
+		s.mu.Lock()
+		// A reconnect may already have replaced this session.
+		if s.sessions[hello.GetDeviceSlug()] == sess {
+			delete(s.sessions, hello.GetDeviceSlug())
+		}
+		s.mu.Unlock()
+		log.Printf("Device %s disconnected", hello.GetDeviceSlug())
+	}()
+
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		reply := msg.GetReply()
+		if reply == nil {
+			continue
+		}
+		if !json.Valid([]byte(reply.GetPayload())) {
+			log.Printf("Dropping malformed reply from %s: %q", hello.GetDeviceSlug(), reply.GetPayload())
+			continue
+		}
+		_, err = s.store.Add(Record{
+			Kind:       KindReply,
+			ReceivedAt: time.Now().UTC(),
+			DeviceName: hello.GetDeviceName(),
+			CommandID:  reply.GetId(),
+			ReplyKind:  reply.GetKind(),
+			Reply:      json.RawMessage(reply.GetPayload()),
+		})
+		if err != nil {
+			log.Printf("Error storing reply from %s: %v", hello.GetDeviceSlug(), err)
+		}
+	}
+}
+
+// SendCommand sends a command payload to the device registered as slug.
+func (s *Server) SendCommand(slug, payload string) error {
+	s.mu.Lock()
+	sess := s.sessions[slug]
+	s.mu.Unlock()
+	if sess == nil {
+		return ErrNotConnected
+	}
+	return sess.send(payload)
+}
+
+// Devices lists the agents with an open command stream.
+func (s *Server) Devices() []DeviceInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := []DeviceInfo{}
+	for _, sess := range s.sessions {
+		out = append(out, DeviceInfo{
+			DeviceName:  sess.hello.GetDeviceName(),
+			DeviceSlug:  sess.hello.GetDeviceSlug(),
+			OSName:      sess.hello.GetOsName(),
+			ConnectedAt: sess.connectedAt,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].DeviceSlug < out[j].DeviceSlug })
+	return out
+}
+
+func (s *Server) listDevices(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.Devices()); err != nil {
+		log.Printf("Error writing devices: %v", err)
+	}
+}
+
+func (s *Server) postCommand(w http.ResponseWriter, r *http.Request) {
+	payload, err := io.ReadAll(io.LimitReader(r.Body, maxCommandSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(payload) == 0 {
+		http.Error(w, "empty command", http.StatusBadRequest)
+		return
+	}
+	switch err := s.SendCommand(r.PathValue("slug"), string(payload)); {
+	case errors.Is(err, ErrNotConnected):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusBadGateway)
+	default:
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
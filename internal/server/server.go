@@ -0,0 +1,182 @@
+// Package server is a reference implementation of ScreenCaptureService. It
+// stores what agents send in a local directory and lists it over HTTP, so the
+// agent can be developed and tested without the production backend.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"capture-screen/internal/storage"
+	pb "capture-screen/src/output"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Message types sent by the agent in ScreenCaptureRequest.messageType.
+const (
+	messageCapture = 0
+	messagePing    = 1
+)
+
+type Server struct {
+	pb.UnimplementedScreenCaptureServiceServer
+	store     *Store
+	images    storage.ImageStore
+	retention storage.Retention
+
+	mu       sync.Mutex
+	sessions map[string]*session
+}
+
+// New creates a server keeping records in store. Images uploaded by agents
+// are kept in images and pruned per device by retention; a nil images
+// store disables uploads.
+func New(store *Store, images storage.ImageStore, retention storage.Retention) *Server {
+	return &Server{
+		store:     store,
+		images:    images,
+		retention: retention,
+		sessions:  make(map[string]*session),
+	}
+}
+
+func (s *Server) SendCapture(ctx context.Context, req *pb.ScreenCaptureRequest) (*pb.ScreenCaptureResponse, error) {
+	if req.GetDeviceName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "deviceName is required")
+	}
+
+	r := Record{
+		ReceivedAt:   time.Now().UTC(),
+		DeviceName:   req.GetDeviceName(),
+		Timestamp:    req.GetTimesTamp(),
+		OSName:       req.GetOsName(),
+		MemoryUsage:  req.GetMemoryUsage(),
+		DiskUsage:    req.GetDiskUsage(),
+		ImageURL:     req.GetLastImage(),
+		ThumbnailURL: req.GetThumbnailUrl(),
+		Memory:       usageRecord(req.GetMemory()),
+		Disk:         usageRecord(req.GetDisk()),
+		Host:         hostRecord(req.GetHost()),
+		VolumesFull:  req.GetVolumesFull(),
+		Unchanged:    req.GetUnchanged(),
+		ChangePct:    req.GetChangePercent(),
+	}
+	if c := req.GetCpu(); c != nil {
+		r.CPU = &CPURecord{Percent: c.GetPercent(), PerCore: c.GetPerCore(), WindowMs: c.GetWindowMs()}
+	}
+	if l := req.GetLoad(); l != nil {
+		r.Load = &LoadRecord{Load1: l.GetLoad1(), Load5: l.GetLoad5(), Load15: l.GetLoad15()}
+	}
+	for _, v := range req.GetVolumes() {
+		r.Volumes = append(r.Volumes, VolumeRecord{
+			Mountpoint:  v.GetMountpoint(),
+			Device:      v.GetDevice(),
+			FSType:      v.GetFsType(),
+			Used:        v.GetUsed(),
+			Total:       v.GetTotal(),
+			UsedPercent: v.GetUsedPercent(),
+			Full:        v.GetFull(),
+		})
+	}
+	for _, n := range req.GetNetwork() {
+		r.Network = append(r.Network, NetRecord{
+			Name:          n.GetName(),
+			RxBytesPerSec: n.GetRxBytesPerSec(),
+			TxBytesPerSec: n.GetTxBytesPerSec(),
+			RxBytes:       n.GetRxBytes(),
+			TxBytes:       n.GetTxBytes(),
+		})
+	}
+	switch req.GetMessageType() {
+	case messageCapture:
+		r.Kind = KindCapture
+	case messagePing:
+		r.Kind = KindPing
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unknown messageType %d", req.GetMessageType())
+	}
+	for _, d := range req.GetDisplays() {
+		r.Displays = append(r.Displays, DisplayRecord{
+			Index:        int(d.GetIndex()),
+			X:            int(d.GetX()),
+			Y:            int(d.GetY()),
+			Width:        int(d.GetWidth()),
+			Height:       int(d.GetHeight()),
+			ImageURL:     d.GetImageUrl(),
+			ThumbnailURL: d.GetThumbnailUrl(),
+		})
+	}
+
+	r, err := s.store.Add(r)
+	if err != nil {
+		log.Printf("Error storing %s from %s: %v", r.Kind, r.DeviceName, err)
+		return nil, status.Error(codes.Internal, "failed to store request")
+	}
+	log.Printf("Stored %s #%d from %s", r.Kind, r.ID, r.DeviceName)
+	return &pb.ScreenCaptureResponse{Success: true, Message: "stored " + r.Kind + " " + strconv.FormatInt(r.ID, 10)}, nil
+}
+
+func usageRecord(u *pb.ResourceUsage) *UsageRecord {
+	if u == nil {
+		return nil
+	}
+	return &UsageRecord{Used: u.GetUsed(), Total: u.GetTotal(), UsedPercent: u.GetUsedPercent()}
+}
+
+func hostRecord(h *pb.HostInfo) *HostRecord {
+	if h == nil {
+		return nil
+	}
+	return &HostRecord{
+		OS:              h.GetOs(),
+		Platform:        h.GetPlatform(),
+		PlatformFamily:  h.GetPlatformFamily(),
+		PlatformVersion: h.GetPlatformVersion(),
+		KernelVersion:   h.GetKernelVersion(),
+		Arch:            h.GetArch(),
+		BootTime:        time.Unix(h.GetBootTime(), 0).UTC(),
+		UptimeSeconds:   h.GetUptimeSeconds(),
+		CPUModel:        h.GetCpuModel(),
+		PhysicalCores:   int(h.GetPhysicalCores()),
+		LogicalCores:    int(h.GetLogicalCores()),
+		AgentVersion:    h.GetAgentVersion(),
+	}
+}
+
+// Handler serves the stored records and lets commands be sent to agents
+// connected over the command stream:
+//
+//	GET  /records?device=<name>&kind=capture|ping|reply&limit=<n>
+//	GET  /devices
+//	POST /devices/{slug}/commands   (body: command payload)
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /records", s.listRecords)
+	mux.HandleFunc("GET /devices", s.listDevices)
+	mux.HandleFunc("POST /devices/{slug}/commands", s.postCommand)
+	return mux
+}
+
+func (s *Server) listRecords(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	f := Filter{Device: q.Get("device"), Kind: q.Get("kind")}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		f.Limit = n
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.store.List(f)); err != nil {
+		log.Printf("Error writing records: %v", err)
+	}
+}
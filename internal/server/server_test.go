@@ -0,0 +1,330 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"capture-screen/internal/storage"
+	pb "capture-screen/src/output"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newTestServer returns a server keeping its records and images in a
+// temporary directory, and a client connected to it over gRPC.
+func newTestServer(t *testing.T, retention storage.Retention) (*Server, pb.ScreenCaptureServiceClient, *storage.LocalStore) {
+	t.Helper()
+	dir := t.TempDir()
+	store, err := OpenStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	images, err := storage.NewLocalStore(filepath.Join(dir, "images"), "http://test/images")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := New(store, images, retention)
+
+	lis := bufconn.Listen(1 << 20)
+	gs := grpc.NewServer()
+	pb.RegisterScreenCaptureServiceServer(gs, srv)
+	go gs.Serve(lis)
+	t.Cleanup(gs.Stop)
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return srv, pb.NewScreenCaptureServiceClient(conn), images
+}
+
+func TestSendCapture(t *testing.T) {
+	srv, client, _ := newTestServer(t, storage.Retention{})
+	ctx := context.Background()
+
+	for _, tt := range []struct {
+		name string
+		req  *pb.ScreenCaptureRequest
+	}{
+		{"missing device", &pb.ScreenCaptureRequest{LastImage: "http://test/a.jpg"}},
+		{"unknown message type", &pb.ScreenCaptureRequest{DeviceName: "PC 1", MessageType: 7}},
+	} {
+		_, err := client.SendCapture(ctx, tt.req)
+		if code := status.Code(err); code != codes.InvalidArgument {
+			t.Errorf("%s: code %v (%v), want %v", tt.name, code, err, codes.InvalidArgument)
+		}
+	}
+	if got := srv.store.List(Filter{}); len(got) != 0 {
+		t.Fatalf("invalid requests stored %+v", got)
+	}
+
+	res, err := client.SendCapture(ctx, &pb.ScreenCaptureRequest{
+		DeviceName:  "PC 1",
+		MessageType: messageCapture,
+		LastImage:   "http://test/a.jpg",
+		Displays:    []*pb.CapturedDisplay{{Index: 1, X: 1920, Width: 1280, Height: 1024, ImageUrl: "http://test/a.jpg"}},
+	})
+	if err != nil || !res.GetSuccess() {
+		t.Fatalf("capture: %v, %v", res, err)
+	}
+	if _, err := client.SendCapture(ctx, &pb.ScreenCaptureRequest{DeviceName: "PC 2", MessageType: messagePing}); err != nil {
+		t.Fatalf("ping: %v", err)
+	}
+
+	got := srv.store.List(Filter{Kind: KindCapture})
+	if len(got) != 1 {
+		t.Fatalf("captures = %+v, want one", got)
+	}
+	want := []DisplayRecord{{Index: 1, X: 1920, Width: 1280, Height: 1024, ImageURL: "http://test/a.jpg"}}
+	if r := got[0]; r.DeviceName != "PC 1" || r.ImageURL != "http://test/a.jpg" || !reflect.DeepEqual(r.Displays, want) {
+		t.Errorf("capture record = %+v", r)
+	}
+	if got := srv.store.List(Filter{Kind: KindPing}); len(got) != 1 || got[0].DeviceName != "PC 2" {
+		t.Errorf("pings = %+v, want one from PC 2", got)
+	}
+}
+
+// upload sends meta followed by data in chunks of chunk bytes.
+func upload(client pb.ScreenCaptureServiceClient, meta *pb.ImageMetadata, data []byte, chunk int) (*pb.UploadImageResponse, error) {
+	stream, err := client.UploadImage(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	if meta != nil {
+		if err := stream.Send(&pb.ImageChunk{Chunk: &pb.ImageChunk_Metadata{Metadata: meta}}); err != nil {
+			return nil, err
+		}
+	}
+	for len(data) > 0 {
+		n := min(len(data), chunk)
+		if err := stream.Send(&pb.ImageChunk{Chunk: &pb.ImageChunk_Data{Data: data[:n]}}); err != nil {
+			break
+		}
+		data = data[n:]
+	}
+	return stream.CloseAndRecv()
+}
+
+func sum(data []byte) string {
+	s := sha256.Sum256(data)
+	return hex.EncodeToString(s[:])
+}
+
+func TestUploadImage(t *testing.T) {
+	_, client, images := newTestServer(t, storage.Retention{})
+	data := []byte(strings.Repeat("image data ", 100))
+	valid := func() *pb.ImageMetadata {
+		return &pb.ImageMetadata{Key: "pc-1/2026-03-01-10-00-00.000.jpg", DeviceName: "PC 1", ContentType: "image/jpeg", Size: int64(len(data)), Sha256: sum(data)}
+	}
+
+	tests := []struct {
+		name string
+		meta func(*pb.ImageMetadata)
+		data []byte
+		code codes.Code
+	}{
+		{"no key", func(m *pb.ImageMetadata) { m.Key = "" }, data, codes.InvalidArgument},
+		{"empty", func(m *pb.ImageMetadata) { m.Size = 0 }, nil, codes.InvalidArgument},
+		{"too large", func(m *pb.ImageMetadata) { m.Size = maxImageSize + 1 }, data, codes.InvalidArgument},
+		{"more data than announced", func(m *pb.ImageMetadata) { m.Size-- }, data, codes.InvalidArgument},
+		{"less data than announced", nil, data[1:], codes.InvalidArgument},
+		{"checksum mismatch", func(m *pb.ImageMetadata) { m.Sha256 = sum(nil) }, data, codes.DataLoss},
+		{"key outside the store", func(m *pb.ImageMetadata) { m.Key = "../pc-1/a.jpg" }, data, codes.InvalidArgument},
+		{"absolute key", func(m *pb.ImageMetadata) { m.Key = "/etc/a.jpg" }, data, codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		meta := valid()
+		if tt.meta != nil {
+			tt.meta(meta)
+		}
+		_, err := upload(client, meta, tt.data, 100)
+		if code := status.Code(err); code != tt.code {
+			t.Errorf("%s: code %v (%v), want %v", tt.name, code, err, tt.code)
+		}
+	}
+	if _, err := upload(client, nil, data, 100); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("data without metadata: %v, want %v", err, codes.InvalidArgument)
+	}
+	if objects, _ := images.List(context.Background(), ""); len(objects) != 0 {
+		t.Fatalf("rejected uploads stored %+v", objects)
+	}
+
+	res, err := upload(client, valid(), data, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "http://test/images/pc-1/2026-03-01-10-00-00.000.jpg"; res.GetUrl() != want {
+		t.Errorf("url = %s, want %s", res.GetUrl(), want)
+	}
+	stored, err := os.ReadFile(filepath.Join(images.Dir, "pc-1", "2026-03-01-10-00-00.000.jpg"))
+	if err != nil || string(stored) != string(data) {
+		t.Errorf("stored %d bytes (%v), want the %d uploaded", len(stored), err, len(data))
+	}
+}
+
+func TestUploadImageRetention(t *testing.T) {
+	_, client, images := newTestServer(t, storage.Retention{Mode: storage.KeepLast, Count: 1})
+	data := []byte("image")
+	put := func(key string, keep ...string) {
+		t.Helper()
+		meta := &pb.ImageMetadata{Key: key, Size: int64(len(data)), Sha256: sum(data), Keep: keep}
+		if _, err := upload(client, meta, data, len(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	put("pc-1/2026-03-01-10-00-00.000.jpg")
+	put("pc-1/2026-03-01-11-00-00.000.jpg")
+	put("pc-2/2026-03-01-11-30-00.000.jpg")
+	put("pc-1/2026-03-01-12-00-00.000.jpg", "pc-1/2026-03-01-11-00-00.000.jpg")
+
+	objects, err := images.List(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, o := range objects {
+		keys = append(keys, o.Key)
+	}
+	want := []string{"pc-1/2026-03-01-11-00-00.000.jpg", "pc-1/2026-03-01-12-00-00.000.jpg", "pc-2/2026-03-01-11-30-00.000.jpg"}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("kept %v, want %v", keys, want)
+	}
+}
+
+func TestListRecords(t *testing.T) {
+	srv, _, _ := newTestServer(t, storage.Retention{})
+	for _, r := range []Record{
+		{Kind: KindCapture, DeviceName: "PC 1"},
+		{Kind: KindPing, DeviceName: "PC 1"},
+		{Kind: KindCapture, DeviceName: "PC 2"},
+		{Kind: KindCapture, DeviceName: "PC 1"},
+	} {
+		if _, err := srv.store.Add(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h := srv.Handler()
+
+	tests := []struct {
+		query  string
+		status int
+		ids    []int64
+	}{
+		{"", http.StatusOK, []int64{4, 3, 2, 1}},
+		{"?device=PC+1", http.StatusOK, []int64{4, 2, 1}},
+		{"?kind=capture", http.StatusOK, []int64{4, 3, 1}},
+		{"?device=PC+1&kind=capture&limit=1", http.StatusOK, []int64{4}},
+		{"?device=PC+3", http.StatusOK, []int64{}},
+		{"?limit=-1", http.StatusBadRequest, nil},
+		{"?limit=all", http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", "/records"+tt.query, nil))
+		if rec.Code != tt.status {
+			t.Errorf("GET /records%s = %d, want %d", tt.query, rec.Code, tt.status)
+			continue
+		}
+		if tt.status != http.StatusOK {
+			continue
+		}
+		var records []Record
+		if err := json.Unmarshal(rec.Body.Bytes(), &records); err != nil {
+			t.Fatalf("GET /records%s: %v", tt.query, err)
+		}
+		ids := []int64{}
+		for _, r := range records {
+			ids = append(ids, r.ID)
+		}
+		if !reflect.DeepEqual(ids, tt.ids) {
+			t.Errorf("GET /records%s = records %v, want %v", tt.query, ids, tt.ids)
+		}
+	}
+}
+
+func TestDeviceCommands(t *testing.T) {
+	srv, client, _ := newTestServer(t, storage.Retention{})
+	h := srv.Handler()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	post := func(slug, body string) int {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("POST", "/devices/"+slug+"/commands", strings.NewReader(body)))
+		return rec.Code
+	}
+	if code := post("pc-1", "ping-device-pc-1"); code != http.StatusNotFound {
+		t.Errorf("command to a disconnected device = %d, want %d", code, http.StatusNotFound)
+	}
+
+	stream, err := client.CommandStream(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hello := &pb.AgentHello{DeviceName: "PC 1", DeviceSlug: "pc-1", OsName: "linux"}
+	if err := stream.Send(&pb.AgentMessage{Message: &pb.AgentMessage_Hello{Hello: hello}}); err != nil {
+		t.Fatal(err)
+	}
+	// The hello is handled asynchronously; a command is sent once it is.
+	var devices []DeviceInfo
+	for len(devices) == 0 {
+		time.Sleep(time.Millisecond)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", "/devices", nil))
+		if err := json.Unmarshal(rec.Body.Bytes(), &devices); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d := devices[0]; len(devices) != 1 || d.DeviceName != "PC 1" || d.DeviceSlug != "pc-1" || d.OSName != "linux" {
+		t.Errorf("devices = %+v, want PC 1", devices)
+	}
+
+	if code := post("pc-1", ""); code != http.StatusBadRequest {
+		t.Errorf("empty command = %d, want %d", code, http.StatusBadRequest)
+	}
+	payload := `{"version":1,"id":"c1","type":"ping-device"}`
+	if code := post("pc-1", payload); code != http.StatusAccepted {
+		t.Fatalf("command = %d, want %d", code, http.StatusAccepted)
+	}
+	cmd, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmd.GetPayload() != payload {
+		t.Errorf("agent received %q, want %q", cmd.GetPayload(), payload)
+	}
+
+	reply := &pb.CommandReply{Id: "c1", Kind: "ack", Payload: `{"id":"c1","kind":"ack"}`}
+	for _, r := range []*pb.CommandReply{{Id: "c1", Kind: "ack", Payload: "not json"}, reply} {
+		if err := stream.Send(&pb.AgentMessage{Message: &pb.AgentMessage_Reply{Reply: r}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stream.CloseSend()
+	// The server ends the stream once it has handled everything sent.
+	if _, err := stream.Recv(); err == nil {
+		t.Fatal("stream still open")
+	}
+	replies := srv.store.List(Filter{Kind: KindReply})
+	if len(replies) != 1 || replies[0].CommandID != "c1" || replies[0].DeviceName != "PC 1" || string(replies[0].Reply) != reply.GetPayload() {
+		t.Errorf("replies = %+v, want the valid ack", replies)
+	}
+}
@@ -0,0 +1,205 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Record kinds. Captures and pings come from SendCapture, replies from the
+// command stream.
+const (
+	KindCapture = "capture"
+	KindPing    = "ping"
+	KindReply   = "reply"
+)
+
+// DisplayRecord is one display of a multi-display capture.
+type DisplayRecord struct {
+	Index        int    `json:"index"`
+	X            int    `json:"x"`
+	Y            int    `json:"y"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+	ImageURL     string `json:"imageUrl,omitempty"`
+	ThumbnailURL string `json:"thumbnailUrl,omitempty"`
+}
+
+// UsageRecord is a used/total pair such as memory or disk usage.
+type UsageRecord struct {
+	Used        uint64  `json:"used"`
+	Total       uint64  `json:"total"`
+	UsedPercent float64 `json:"usedPercent"`
+}
+
+// HostRecord is the host inventory an agent sent with a capture or ping.
+type HostRecord struct {
+	OS              string    `json:"os"`
+	Platform        string    `json:"platform"`
+	PlatformFamily  string    `json:"platformFamily,omitempty"`
+	PlatformVersion string    `json:"platformVersion"`
+	KernelVersion   string    `json:"kernelVersion"`
+	Arch            string    `json:"arch"`
+	BootTime        time.Time `json:"bootTime"`
+	UptimeSeconds   int64     `json:"uptimeSeconds"`
+	CPUModel        string    `json:"cpuModel"`
+	PhysicalCores   int       `json:"physicalCores"`
+	LogicalCores    int       `json:"logicalCores"`
+	AgentVersion    string    `json:"agentVersion"`
+}
+
+// CPURecord is CPU utilisation in percent over WindowMs.
+type CPURecord struct {
+	Percent  float64   `json:"percent"`
+	PerCore  []float64 `json:"perCore"`
+	WindowMs int64     `json:"windowMs"`
+}
+
+type LoadRecord struct {
+	Load1  float64 `json:"load1"`
+	Load5  float64 `json:"load5"`
+	Load15 float64 `json:"load15"`
+}
+
+// NetRecord is the traffic of one network interface.
+type NetRecord struct {
+	Name          string  `json:"name"`
+	RxBytesPerSec float64 `json:"rxBytesPerSec"`
+	TxBytesPerSec float64 `json:"txBytesPerSec"`
+	RxBytes       uint64  `json:"rxBytes"`
+	TxBytes       uint64  `json:"txBytes"`
+}
+
+// VolumeRecord is the usage of one mounted filesystem.
+type VolumeRecord struct {
+	Mountpoint  string  `json:"mountpoint"`
+	Device      string  `json:"device"`
+	FSType      string  `json:"fsType"`
+	Used        uint64  `json:"used"`
+	Total       uint64  `json:"total"`
+	UsedPercent float64 `json:"usedPercent"`
+	Full        bool    `json:"full"`
+}
+
+// Record is a capture or ping received from an agent.
+type Record struct {
+	ID           int64           `json:"id"`
+	Kind         string          `json:"kind"`
+	ReceivedAt   time.Time       `json:"receivedAt"`
+	DeviceName   string          `json:"deviceName"`
+	Timestamp    string          `json:"timestamp,omitempty"`
+	OSName       string          `json:"osName,omitempty"`
+	MemoryUsage  string          `json:"memoryUsage,omitempty"`
+	DiskUsage    string          `json:"diskUsage,omitempty"`
+	Memory       *UsageRecord    `json:"memory,omitempty"`
+	Disk         *UsageRecord    `json:"disk,omitempty"`
+	Host         *HostRecord     `json:"host,omitempty"`
+	CPU          *CPURecord      `json:"cpu,omitempty"`
+	Load         *LoadRecord     `json:"load,omitempty"`
+	Network      []NetRecord     `json:"network,omitempty"`
+	Volumes      []VolumeRecord  `json:"volumes,omitempty"`
+	VolumesFull  bool            `json:"volumesFull,omitempty"`
+	Unchanged    bool            `json:"unchanged,omitempty"`
+	ChangePct    float64         `json:"changePercent,omitempty"`
+	ImageURL     string          `json:"imageUrl,omitempty"`
+	ThumbnailURL string          `json:"thumbnailUrl,omitempty"`
+	Displays     []DisplayRecord `json:"displays,omitempty"`
+	CommandID    string          `json:"commandId,omitempty"`
+	ReplyKind    string          `json:"replyKind,omitempty"`
+	Reply        json.RawMessage `json:"reply,omitempty"`
+}
+
+// Filter selects records in Store.List. Zero fields match everything.
+type Filter struct {
+	Device string
+	Kind   string
+	Limit  int
+}
+
+// Store keeps records in memory and appends them to a JSON lines file so they
+// survive restarts.
+type Store struct {
+	mu      sync.RWMutex
+	file    *os.File
+	records []Record
+	nextID  int64
+}
+
+const recordsFile = "records.jsonl"
+
+// OpenStore loads the records kept in dir, creating it if needed.
+func OpenStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	path := filepath.Join(dir, recordsFile)
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Store{file: f, nextID: 1}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("%s:%d: %v", path, line, err)
+		}
+		s.records = append(s.records, r)
+		if r.ID >= s.nextID {
+			s.nextID = r.ID + 1
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Add assigns r an ID and persists it.
+func (s *Store) Add(r Record) (Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r.ID = s.nextID
+	line, err := json.Marshal(r)
+	if err != nil {
+		return Record{}, err
+	}
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return Record{}, err
+	}
+	s.nextID++
+	s.records = append(s.records, r)
+	return r, nil
+}
+
+// List returns matching records, newest first.
+func (s *Store) List(f Filter) []Record {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := []Record{}
+	for i := len(s.records) - 1; i >= 0; i-- {
+		r := s.records[i]
+		if (f.Device != "" && r.DeviceName != f.Device) || (f.Kind != "" && r.Kind != f.Kind) {
+			continue
+		}
+		out = append(out, r)
+		if f.Limit > 0 && len(out) == f.Limit {
+			break
+		}
+	}
+	return out
+}
+
+func (s *Store) Close() error {
+	return s.file.Close()
+}
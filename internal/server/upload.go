@@ -0,0 +1,101 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"path"
+	"time"
+
+	"capture-screen/internal/storage"
+	pb "capture-screen/src/output"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxImageSize bounds a single uploaded image.
+const maxImageSize = 64 << 20
+
+func (s *Server) UploadImage(stream pb.ScreenCaptureService_UploadImageServer) error {
+	if s.images == nil {
+		return status.Error(codes.Unimplemented, "image uploads are disabled on this server")
+	}
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	meta := first.GetMetadata()
+	switch {
+	case meta == nil:
+		return status.Error(codes.InvalidArgument, "the first chunk must carry the metadata")
+	case meta.GetKey() == "":
+		return status.Error(codes.InvalidArgument, "key is required")
+	case meta.GetSize() <= 0 || meta.GetSize() > maxImageSize:
+		return status.Errorf(codes.InvalidArgument, "size must be between 1 and %d bytes", maxImageSize)
+	}
+
+	data := make([]byte, 0, meta.GetSize())
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if chunk.GetMetadata() != nil {
+			return status.Error(codes.InvalidArgument, "metadata sent twice")
+		}
+		if int64(len(data)+len(chunk.GetData())) > meta.GetSize() {
+			return status.Errorf(codes.InvalidArgument, "more data than the announced %d bytes", meta.GetSize())
+		}
+		data = append(data, chunk.GetData()...)
+	}
+	if int64(len(data)) != meta.GetSize() {
+		return status.Errorf(codes.InvalidArgument, "received %d of %d bytes", len(data), meta.GetSize())
+	}
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != meta.GetSha256() {
+		return status.Error(codes.DataLoss, "checksum mismatch")
+	}
+
+	ctx := stream.Context()
+	if err := s.images.Put(ctx, meta.GetKey(), data, meta.GetContentType()); err != nil {
+		log.Printf("Error storing %s: %v", meta.GetKey(), err)
+		if errors.Is(err, storage.ErrInvalidKey) {
+			return status.Errorf(codes.InvalidArgument, "invalid key %q", meta.GetKey())
+		}
+		return status.Errorf(codes.Internal, "failed to store %s", meta.GetKey())
+	}
+	url, err := s.images.URL(ctx, meta.GetKey())
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to build URL for %s", meta.GetKey())
+	}
+	log.Printf("Stored %s from %s (%s %dx%d, %d bytes)", meta.GetKey(), meta.GetDeviceName(), meta.GetFormat(), meta.GetWidth(), meta.GetHeight(), meta.GetSize())
+
+	if err := s.prune(ctx, path.Dir(meta.GetKey())+"/", meta.GetKeep()); err != nil {
+		log.Printf("Error applying retention policy to %s: %v", meta.GetKey(), err)
+	}
+	return stream.SendAndClose(&pb.UploadImageResponse{Url: url})
+}
+
+// prune applies the retention policy to the images under prefix, which is
+// the uploading device's folder. Captures holding a keep key are left alone.
+func (s *Server) prune(ctx context.Context, prefix string, keep []string) error {
+	if s.retention.Mode == storage.KeepAll {
+		return nil
+	}
+	objects, err := s.images.List(ctx, prefix)
+	if err != nil {
+		return err
+	}
+	expired := s.retention.Expired(prefix, objects, time.Now(), keep...)
+	if len(expired) == 0 {
+		return nil
+	}
+	return s.images.Delete(ctx, expired...)
+}
@@ -0,0 +1,198 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CloudinaryStore talks to the Cloudinary upload and admin REST APIs, or to
+// any service that implements them.
+type CloudinaryStore struct {
+	CloudName string
+	APIKey    string
+	APISecret string
+	// APIURL and DeliveryURL default to Cloudinary's own hosts.
+	APIURL      string
+	DeliveryURL string
+
+	client *http.Client
+}
+
+// cloudinaryDeleteBatch is the most public IDs one delete call accepts.
+const cloudinaryDeleteBatch = 100
+
+func NewCloudinaryStore(cloudName, apiKey, apiSecret string) (*CloudinaryStore, error) {
+	if cloudName == "" || apiKey == "" || apiSecret == "" {
+		return nil, fmt.Errorf("cloudinary store needs a cloud name, API key and API secret")
+	}
+	return &CloudinaryStore{
+		CloudName:   cloudName,
+		APIKey:      apiKey,
+		APISecret:   apiSecret,
+		APIURL:      "https://api.cloudinary.com",
+		DeliveryURL: "https://res.cloudinary.com",
+		client:      &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// publicID strips the extension: Cloudinary stores the format separately.
+func publicID(key string) string {
+	return strings.TrimSuffix(key, path.Ext(key))
+}
+
+func (s *CloudinaryStore) endpoint(p string) string {
+	return strings.TrimSuffix(s.APIURL, "/") + "/v1_1/" + s.CloudName + p
+}
+
+// sign computes the request signature: the parameters sorted by name and
+// joined as unescaped "k=v" pairs, followed by the API secret.
+func (s *CloudinaryStore) sign(params url.Values) string {
+	names := make([]string, 0, len(params))
+	for k := range params {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	pairs := make([]string, len(names))
+	for i, k := range names {
+		pairs[i] = k + "=" + params.Get(k)
+	}
+	sum := sha1.Sum([]byte(strings.Join(pairs, "&") + s.APISecret))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *CloudinaryStore) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	params := url.Values{
+		"public_id": {publicID(key)},
+		"timestamp": {strconv.FormatInt(time.Now().Unix(), 10)},
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for k := range params {
+		mw.WriteField(k, params.Get(k))
+	}
+	mw.WriteField("api_key", s.APIKey)
+	mw.WriteField("signature", s.sign(params))
+	fw, err := mw.CreateFormFile("file", path.Base(key))
+	if err != nil {
+		return err
+	}
+	if _, err := fw.Write(data); err != nil {
+		return err
+	}
+	if err := mw.Close(); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint("/image/upload"), &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return s.do(req, nil)
+}
+
+func (s *CloudinaryStore) Delete(ctx context.Context, keys ...string) error {
+	for start := 0; start < len(keys); start += cloudinaryDeleteBatch {
+		end := min(start+cloudinaryDeleteBatch, len(keys))
+		q := url.Values{}
+		for _, key := range keys[start:end] {
+			q.Add("public_ids[]", publicID(key))
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.endpoint("/resources/image/upload")+"?"+q.Encode(), nil)
+		if err != nil {
+			return err
+		}
+		req.SetBasicAuth(s.APIKey, s.APISecret)
+		if err := s.do(req, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *CloudinaryStore) List(ctx context.Context, prefix string) ([]Object, error) {
+	var objects []Object
+	cursor := ""
+	for {
+		q := url.Values{"prefix": {prefix}, "max_results": {"500"}}
+		if cursor != "" {
+			q.Set("next_cursor", cursor)
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.endpoint("/resources/image/upload")+"?"+q.Encode(), nil)
+		if err != nil {
+			return nil, err
+		}
+		req.SetBasicAuth(s.APIKey, s.APISecret)
+
+		var page struct {
+			Resources []struct {
+				PublicID  string    `json:"public_id"`
+				Format    string    `json:"format"`
+				Bytes     int64     `json:"bytes"`
+				CreatedAt time.Time `json:"created_at"`
+			} `json:"resources"`
+			NextCursor string `json:"next_cursor"`
+		}
+		if err := s.do(req, &page); err != nil {
+			return nil, err
+		}
+		for _, r := range page.Resources {
+			objects = append(objects, Object{
+				Key:     r.PublicID + "." + r.Format,
+				Size:    r.Bytes,
+				ModTime: r.CreatedAt,
+			})
+		}
+		if page.NextCursor == "" {
+			return objects, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+func (s *CloudinaryStore) URL(ctx context.Context, key string) (string, error) {
+	u := strings.TrimSuffix(s.DeliveryURL, "/") + "/" + s.CloudName + "/image/upload/"
+	return u + (&url.URL{Path: key}).EscapedPath(), nil
+}
+
+func (s *CloudinaryStore) do(req *http.Request, out interface{}) error {
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Error.Message != "" {
+			return fmt.Errorf("cloudinary: %s (%d)", apiErr.Error.Message, resp.StatusCode)
+		}
+		return fmt.Errorf("cloudinary: unexpected status %d", resp.StatusCode)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(body, out)
+}
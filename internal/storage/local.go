@@ -0,0 +1,156 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStore keeps captures in a directory. When BaseURL is set, URL points
+// at it, usually the file server returned by Handler; otherwise it returns
+// file:// URLs.
+type LocalStore struct {
+	Dir     string
+	BaseURL string
+}
+
+func NewLocalStore(dir, baseURL string) (*LocalStore, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("local store needs a directory")
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(abs, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", abs, err)
+	}
+	return &LocalStore{Dir: abs, BaseURL: strings.TrimSuffix(baseURL, "/")}, nil
+}
+
+// ErrInvalidKey is returned for keys that would escape the store's directory.
+var ErrInvalidKey = errors.New("invalid key")
+
+func (s *LocalStore) path(key string) (string, error) {
+	p := filepath.FromSlash(key)
+	if !filepath.IsLocal(p) {
+		return "", fmt.Errorf("%w %q", ErrInvalidKey, key)
+	}
+	return filepath.Join(s.Dir, p), nil
+}
+
+func (s *LocalStore) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	// Write to a temporary file first so the file server never serves a
+	// half-written image.
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, p)
+}
+
+func (s *LocalStore) Delete(ctx context.Context, keys ...string) error {
+	for _, key := range keys {
+		p, err := s.path(key)
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *LocalStore) List(ctx context.Context, prefix string) ([]Object, error) {
+	// Walk the deepest directory named by prefix and filter on the rest.
+	dir := path.Dir(prefix + "x")
+	root, err := s.path(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []Object
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return fs.SkipAll
+			}
+			return err
+		}
+		if d.IsDir() || strings.HasSuffix(p, ".tmp") {
+			return nil
+		}
+		rel, err := filepath.Rel(s.Dir, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, Object{Key: key, Size: info.Size(), ModTime: info.ModTime()})
+		return nil
+	})
+	return objects, err
+}
+
+func (s *LocalStore) URL(ctx context.Context, key string) (string, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return "", err
+	}
+	if s.BaseURL == "" {
+		return (&url.URL{Scheme: "file", Path: filepath.ToSlash(p)}).String(), nil
+	}
+	return s.BaseURL + (&url.URL{Path: "/" + key}).EscapedPath(), nil
+}
+
+// Handler serves the stored captures over HTTP. Only stored keys are served:
+// directories aren't listed and uploads still being written aren't found.
+func (s *LocalStore) Handler() http.Handler {
+	return http.FileServer(keysOnly{http.Dir(s.Dir)})
+}
+
+// keysOnly hides everything in a file system except finished files.
+type keysOnly struct {
+	http.FileSystem
+}
+
+func (k keysOnly) Open(name string) (http.File, error) {
+	if strings.HasSuffix(name, ".tmp") {
+		return nil, fs.ErrNotExist
+	}
+	f, err := k.FileSystem.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if !info.Mode().IsRegular() {
+		f.Close()
+		return nil, fs.ErrNotExist
+	}
+	return f, nil
+}
@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalStoreHandler(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewLocalStore(filepath.Join(dir, "captures"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(context.Background(), "dev/2026-03-01-10-00-00.000.jpg", []byte("jpeg"), "image/jpeg"); err != nil {
+		t.Fatal(err)
+	}
+	// An upload still being written.
+	if err := os.WriteFile(filepath.Join(store.Dir, "dev", "2026-03-01-11-00-00.000.jpg.tmp"), []byte("jp"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "secret.txt"), []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(store.Handler())
+	defer srv.Close()
+
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/dev/2026-03-01-10-00-00.000.jpg", http.StatusOK},
+		{"/", http.StatusNotFound},
+		{"/dev", http.StatusNotFound},
+		{"/dev/", http.StatusNotFound},
+		{"/dev/index.html", http.StatusNotFound},
+		{"/dev/2026-03-01-11-00-00.000.jpg.tmp", http.StatusNotFound},
+		{"/../secret.txt", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		res, err := http.Get(srv.URL + tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+		if res.StatusCode != tt.status {
+			t.Errorf("GET %s = %d %q, want %d", tt.path, res.StatusCode, body, tt.status)
+		}
+		if tt.status == http.StatusOK && string(body) != "jpeg" {
+			t.Errorf("GET %s = %q, want the stored data", tt.path, body)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type RetentionMode int
+
+const (
+	KeepAll RetentionMode = iota
+	KeepLast
+	KeepFor
+)
+
+// Retention decides which of a device's previous captures survive a new
+// upload.
+type Retention struct {
+	Mode   RetentionMode
+	Count  int
+	MaxAge time.Duration
+}
+
+// ParseRetention reads a policy of the form "keep-all", "keep-last:<n>" or
+// "keep-for:<duration>", e.g. "keep-last:10" or "keep-for:168h".
+func ParseRetention(s string) (Retention, error) {
+	name, value, _ := strings.Cut(s, ":")
+	switch name {
+	case "keep-all":
+		return Retention{Mode: KeepAll}, nil
+	case "keep-last":
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return Retention{}, fmt.Errorf("invalid retention %q: keep-last needs a count of at least 1", s)
+		}
+		return Retention{Mode: KeepLast, Count: n}, nil
+	case "keep-for":
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return Retention{}, fmt.Errorf("invalid retention %q: keep-for needs a positive duration", s)
+		}
+		return Retention{Mode: KeepFor, MaxAge: d}, nil
+	default:
+		return Retention{}, fmt.Errorf("unknown retention policy %q", s)
+	}
+}
+
+// capture is every object uploaded by one Upload call. They share the
+// timestamp at the start of the name.
+type capture struct {
+	stamp    string
+	modified time.Time
+	keys     []string
+}
+
+// Expired returns the keys under prefix that fall outside the policy. The
+// newest capture is always kept, and so is every capture holding one of the
+// keep keys.
+func (r Retention) Expired(prefix string, objects []Object, now time.Time, keep ...string) []string {
+	if r.Mode == KeepAll {
+		return nil
+	}
+
+	kept := make(map[string]bool, len(keep))
+	for _, k := range keep {
+		kept[k] = true
+	}
+	var expired []string
+	for i, c := range groupCaptures(prefix, objects) {
+		switch {
+		case i == 0:
+		case c.holdsAny(kept):
+		case r.Mode == KeepLast && i >= r.Count:
+			expired = append(expired, c.keys...)
+		case r.Mode == KeepFor && c.modified.Before(now.Add(-r.MaxAge)):
+			expired = append(expired, c.keys...)
+		}
+	}
+	return expired
+}
+
+func (c *capture) holdsAny(keys map[string]bool) bool {
+	for _, k := range c.keys {
+		if keys[k] {
+			return true
+		}
+	}
+	return false
+}
+
+// groupCaptures groups objects by capture, newest first.
+func groupCaptures(prefix string, objects []Object) []*capture {
+	byStamp := map[string]*capture{}
+	for _, obj := range objects {
+		stamp := strings.TrimPrefix(obj.Key, prefix)
+		// Objects in folders below prefix are pruned with their folder.
+		if strings.Contains(stamp, "/") {
+			continue
+		}
+		if len(stamp) > len(stampFormat) {
+			stamp = stamp[:len(stampFormat)]
+		}
+		c, ok := byStamp[stamp]
+		if !ok {
+			c = &capture{stamp: stamp}
+			byStamp[stamp] = c
+		}
+		c.keys = append(c.keys, obj.Key)
+		if obj.ModTime.After(c.modified) {
+			c.modified = obj.ModTime
+		}
+	}
+
+	captures := make([]*capture, 0, len(byStamp))
+	for _, c := range byStamp {
+		captures = append(captures, c)
+	}
+	sort.Slice(captures, func(i, j int) bool {
+		if !captures[i].modified.Equal(captures[j].modified) {
+			return captures[i].modified.After(captures[j].modified)
+		}
+		return captures[i].stamp > captures[j].stamp
+	})
+	return captures
+}
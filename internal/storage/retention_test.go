@@ -0,0 +1,169 @@
+package storage
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestParseRetention(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Retention
+		wantErr bool
+	}{
+		{in: "keep-all", want: Retention{Mode: KeepAll}},
+		{in: "keep-last:3", want: Retention{Mode: KeepLast, Count: 3}},
+		{in: "keep-for:168h", want: Retention{Mode: KeepFor, MaxAge: 168 * time.Hour}},
+		{in: "keep-last:0", wantErr: true},
+		{in: "keep-last", wantErr: true},
+		{in: "keep-for:-1h", wantErr: true},
+		{in: "keep-for:soon", wantErr: true},
+		{in: "forever", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseRetention(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseRetention(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseRetention(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestExpired(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	// obj is a key under "shots/dev/" uploaded age ago.
+	obj := func(name string, age time.Duration) Object {
+		return Object{Key: "shots/dev/" + name, ModTime: now.Add(-age)}
+	}
+
+	tests := []struct {
+		name      string
+		retention Retention
+		objects   []Object
+		keep      []string
+		want      []string
+	}{
+		{
+			name:      "keep-all",
+			retention: Retention{Mode: KeepAll},
+			objects: []Object{
+				obj("2026-03-01-10-00-00.000.jpg", 2*time.Hour),
+				obj("2026-03-01-11-00-00.000.jpg", time.Hour),
+			},
+		},
+		{
+			name:      "keep-last",
+			retention: Retention{Mode: KeepLast, Count: 2},
+			objects: []Object{
+				obj("2026-03-01-09-00-00.000.jpg", 3*time.Hour),
+				obj("2026-03-01-10-00-00.000.jpg", 2*time.Hour),
+				obj("2026-03-01-11-00-00.000.jpg", time.Hour),
+			},
+			want: []string{"2026-03-01-09-00-00.000.jpg"},
+		},
+		{
+			name:      "keep-for",
+			retention: Retention{Mode: KeepFor, MaxAge: 90 * time.Minute},
+			objects: []Object{
+				obj("2026-03-01-09-00-00.000.jpg", 3*time.Hour),
+				obj("2026-03-01-10-00-00.000.jpg", 2*time.Hour),
+				obj("2026-03-01-11-00-00.000.jpg", time.Hour),
+			},
+			want: []string{"2026-03-01-09-00-00.000.jpg", "2026-03-01-10-00-00.000.jpg"},
+		},
+		{
+			name:      "keep-for keeps the newest capture however old",
+			retention: Retention{Mode: KeepFor, MaxAge: time.Hour},
+			objects: []Object{
+				obj("2026-02-01-10-00-00.000.jpg", 30*24*time.Hour),
+				obj("2026-02-02-10-00-00.000.jpg", 29*24*time.Hour),
+			},
+			want: []string{"2026-02-01-10-00-00.000.jpg"},
+		},
+		{
+			name:      "thumbnails go with their image",
+			retention: Retention{Mode: KeepLast, Count: 1},
+			objects: []Object{
+				obj("2026-03-01-10-00-00.000.png", 2*time.Hour),
+				obj("2026-03-01-10-00-00.000-thumb.jpg", 2*time.Hour),
+				obj("2026-03-01-11-00-00.000.png", time.Hour),
+				obj("2026-03-01-11-00-00.000-thumb.jpg", time.Hour),
+			},
+			want: []string{"2026-03-01-10-00-00.000.png", "2026-03-01-10-00-00.000-thumb.jpg"},
+		},
+		{
+			name:      "displays of one capture share a stamp",
+			retention: Retention{Mode: KeepLast, Count: 1},
+			objects: []Object{
+				obj("2026-03-01-10-00-00.000-0.jpg", 2*time.Hour),
+				obj("2026-03-01-10-00-00.000-1.jpg", 2*time.Hour),
+				obj("2026-03-01-10-00-00.000-1-thumb.jpg", 2*time.Hour),
+				obj("2026-03-01-11-00-00.000-0.jpg", time.Hour),
+				// Written a moment later than the first display.
+				obj("2026-03-01-11-00-00.000-1.jpg", time.Hour-time.Second),
+			},
+			want: []string{"2026-03-01-10-00-00.000-0.jpg", "2026-03-01-10-00-00.000-1.jpg", "2026-03-01-10-00-00.000-1-thumb.jpg"},
+		},
+		{
+			name:      "legacy keys",
+			retention: Retention{Mode: KeepLast, Count: 1},
+			objects: []Object{
+				obj("2026-03-01-09-00-00.png", 3*time.Hour),
+				obj("2026-03-01-10-00-00.png", 2*time.Hour),
+				obj("2026-03-01-11-00-00.000.jpg", time.Hour),
+			},
+			want: []string{"2026-03-01-09-00-00.png", "2026-03-01-10-00-00.png"},
+		},
+		{
+			name:      "newest by modification time",
+			retention: Retention{Mode: KeepLast, Count: 1},
+			objects: []Object{
+				// A clock change made the newer upload's stamp smaller.
+				obj("2026-03-01-11-00-00.000.jpg", 2*time.Hour),
+				obj("2026-03-01-10-00-00.000.jpg", time.Hour),
+			},
+			want: []string{"2026-03-01-11-00-00.000.jpg"},
+		},
+		{
+			name:      "pinned captures are kept",
+			retention: Retention{Mode: KeepLast, Count: 1},
+			objects: []Object{
+				obj("2026-03-01-09-00-00.000.jpg", 3*time.Hour),
+				obj("2026-03-01-10-00-00.000.jpg", 2*time.Hour),
+				obj("2026-03-01-10-00-00.000-thumb.jpg", 2*time.Hour),
+				obj("2026-03-01-11-00-00.000.jpg", time.Hour),
+			},
+			keep: []string{"shots/dev/2026-03-01-10-00-00.000.jpg"},
+			want: []string{"2026-03-01-09-00-00.000.jpg"},
+		},
+		{
+			name:      "folders below the prefix are left alone",
+			retention: Retention{Mode: KeepLast, Count: 1},
+			objects: []Object{
+				obj("2026-03-01-10-00-00.000.jpg", 2*time.Hour),
+				obj("bursts/2026-03-01-10-30-00.000-burst.gif", 90*time.Minute),
+				obj("2026-03-01-11-00-00.000.jpg", time.Hour),
+			},
+			want: []string{"2026-03-01-10-00-00.000.jpg"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.retention.Expired("shots/dev/", tt.objects, now, tt.keep...)
+			var want []string
+			for _, name := range tt.want {
+				want = append(want, "shots/dev/"+name)
+			}
+			sort.Strings(got)
+			sort.Strings(want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Expired() = %v, want %v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Object is a stored image as reported by List.
+type Object struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+// ImageStore is a place captures can be uploaded to. Keys are slash
+// separated paths such as "<folder>/<device>/<stamp>.jpg".
+type ImageStore interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Delete(ctx context.Context, keys ...string) error
+	List(ctx context.Context, prefix string) ([]Object, error)
+	URL(ctx context.Context, key string) (string, error)
+}
+
+// Image is a single object uploaded with a capture. Name is appended to the
+// capture timestamp to build the object key and should carry the extension.
+type Image struct {
+	Name        string
+	Data        []byte
+	ContentType string
+}
+
+// stampFormat starts every object name so the objects of one capture can be
+// grouped back together.
+const stampFormat = "2006-01-02-15-04-05.000"
+
+// Stored is where one uploaded image ended up.
+type Stored struct {
+	Key string
+	URL string
+}
+
+// Pinner is implemented by stores that apply retention on their own, such as
+// the gRPC upload store, so pinned keys can be passed on to them.
+type Pinner interface {
+	Pin(keys ...string)
+	Unpin(keys ...string)
+}
+
+// Captures uploads capture batches under Folder and prunes old ones.
+type Captures struct {
+	Store     ImageStore
+	Folder    string
+	Retention Retention
+
+	mu     sync.Mutex
+	pinned map[string]bool
+}
+
+// Pin keeps the captures holding keys out of retention until they are
+// unpinned, for uploads that are still being referred to.
+func (c *Captures) Pin(keys ...string) {
+	c.mu.Lock()
+	if c.pinned == nil {
+		c.pinned = make(map[string]bool)
+	}
+	for _, k := range keys {
+		c.pinned[k] = true
+	}
+	c.mu.Unlock()
+	if p, ok := c.Store.(Pinner); ok {
+		p.Pin(keys...)
+	}
+}
+
+func (c *Captures) Unpin(keys ...string) {
+	c.mu.Lock()
+	for _, k := range keys {
+		delete(c.pinned, k)
+	}
+	c.mu.Unlock()
+	if p, ok := c.Store.(Pinner); ok {
+		p.Unpin(keys...)
+	}
+}
+
+func (c *Captures) pinnedKeys() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	keys := make([]string, 0, len(c.pinned))
+	for k := range c.pinned {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func (c *Captures) prefix(deviceName string) string {
+	if c.Folder == "" {
+		return deviceName + "/"
+	}
+	return c.Folder + "/" + deviceName + "/"
+}
+
+// Upload stores images as one capture and returns their keys and URLs in
+// the same order. Older captures are pruned by the retention policy only once every
+// upload succeeded, so a failed upload never leaves the device without an
+// image.
+func (c *Captures) Upload(ctx context.Context, deviceName string, images []Image) ([]Stored, error) {
+	return c.UploadTo(ctx, deviceName, "", images)
+}
+
+// UploadTo is Upload into a folder below the device's captures, such as
+// "bursts". Every folder is pruned on its own, so uploads to one never push
+// captures out of another.
+func (c *Captures) UploadTo(ctx context.Context, deviceName, folder string, images []Image) ([]Stored, error) {
+	prefix := c.prefix(deviceName)
+	if folder != "" {
+		prefix += folder + "/"
+	}
+	stamp := time.Now().Format(stampFormat)
+	stored := make([]Stored, 0, len(images))
+	for _, image := range images {
+		key := prefix + stamp + image.Name
+		if err := c.Store.Put(ctx, key, image.Data, image.ContentType); err != nil {
+			return nil, fmt.Errorf("failed to upload %s: %w", key, err)
+		}
+		url, err := c.Store.URL(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		stored = append(stored, Stored{Key: key, URL: url})
+	}
+
+	if err := c.prune(ctx, prefix); err != nil {
+		log.Printf("Error applying retention policy for %s: %v", deviceName, err)
+	}
+	return stored, nil
+}
+
+func (c *Captures) prune(ctx context.Context, prefix string) error {
+	if c.Retention.Mode == KeepAll {
+		return nil
+	}
+	objects, err := c.Store.List(ctx, prefix)
+	if err != nil {
+		return err
+	}
+	expired := c.Retention.Expired(prefix, objects, time.Now(), c.pinnedKeys()...)
+	if len(expired) == 0 {
+		return nil
+	}
+	return c.Store.Delete(ctx, expired...)
+}
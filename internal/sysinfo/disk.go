@@ -0,0 +1,117 @@
+package sysinfo
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/disk"
+)
+
+// Volume is the usage of one mounted filesystem.
+type Volume struct {
+	Mountpoint  string  `json:"mountpoint"`
+	Device      string  `json:"device"`
+	FSType      string  `json:"fsType"`
+	Used        uint64  `json:"used"`
+	Total       uint64  `json:"total"`
+	UsedPercent float64 `json:"usedPercent"`
+	// Full is set when UsedPercent reaches the configured threshold.
+	Full bool `json:"full"`
+}
+
+// VolumeFilter selects the volumes reported. Include and Exclude hold mount
+// points or glob patterns such as "/mnt/*"; an empty Include reports every
+// volume that isn't excluded. Exclude wins over Include.
+type VolumeFilter struct {
+	Include       []string
+	Exclude       []string
+	FullThreshold float64
+}
+
+// pseudoFilesystems don't hold user data and only clutter the report.
+var pseudoFilesystems = map[string]bool{
+	"autofs": true, "binfmt_misc": true, "bpf": true, "cgroup": true,
+	"cgroup2": true, "configfs": true, "debugfs": true, "devfs": true,
+	"devpts": true, "devtmpfs": true, "efivarfs": true, "fusectl": true,
+	"hugetlbfs": true, "mqueue": true, "nsfs": true, "overlay": true,
+	"proc": true, "pstore": true, "ramfs": true, "securityfs": true,
+	"squashfs": true, "sysfs": true, "tmpfs": true, "tracefs": true,
+}
+
+// Volumes reports usage for every mounted filesystem that passes f.
+func Volumes(ctx context.Context, f VolumeFilter) ([]Volume, error) {
+	parts, err := disk.PartitionsWithContext(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("error listing partitions: %v", err)
+	}
+
+	volumes := []Volume{}
+	seen := make(map[string]bool)
+	for _, p := range parts {
+		if pseudoFilesystems[strings.ToLower(p.Fstype)] || seen[p.Mountpoint] || !f.matches(p.Mountpoint) {
+			continue
+		}
+		seen[p.Mountpoint] = true
+		u, err := disk.UsageWithContext(ctx, p.Mountpoint)
+		if err != nil || u.Total == 0 {
+			// Empty drives and mounts we can't stat have nothing to report.
+			continue
+		}
+		volumes = append(volumes, Volume{
+			Mountpoint:  p.Mountpoint,
+			Device:      p.Device,
+			FSType:      p.Fstype,
+			Used:        u.Used,
+			Total:       u.Total,
+			UsedPercent: u.UsedPercent,
+			Full:        f.FullThreshold > 0 && u.UsedPercent >= f.FullThreshold,
+		})
+	}
+	sort.Slice(volumes, func(i, j int) bool { return volumes[i].Mountpoint < volumes[j].Mountpoint })
+	return volumes, nil
+}
+
+// AnyFull reports whether any volume is above the fullness threshold.
+func AnyFull(volumes []Volume) bool {
+	for _, v := range volumes {
+		if v.Full {
+			return true
+		}
+	}
+	return false
+}
+
+func (f VolumeFilter) matches(mountpoint string) bool {
+	if matchAny(f.Exclude, mountpoint) {
+		return false
+	}
+	return len(f.Include) == 0 || matchAny(f.Include, mountpoint)
+}
+
+// matchAny matches plain entries against the mount point exactly and glob
+// patterns against it and its parent directories, so "/mnt/*" also covers
+// "/mnt/data/backups".
+func matchAny(patterns []string, mountpoint string) bool {
+	for _, p := range patterns {
+		if !strings.ContainsAny(p, "*?[") {
+			if strings.EqualFold(p, mountpoint) {
+				return true
+			}
+			continue
+		}
+		for m := mountpoint; ; {
+			if ok, _ := filepath.Match(p, m); ok {
+				return true
+			}
+			parent := filepath.Dir(m)
+			if parent == m {
+				break
+			}
+			m = parent
+		}
+	}
+	return false
+}
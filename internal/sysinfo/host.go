@@ -0,0 +1,84 @@
+// Package sysinfo collects the host details agents report with pings.
+package sysinfo
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/host"
+)
+
+// Host is the inventory of the machine the agent runs on. It doesn't change
+// while the agent runs, so it is collected once.
+type Host struct {
+	OS              string    `json:"os"`
+	Platform        string    `json:"platform"`
+	PlatformFamily  string    `json:"platformFamily,omitempty"`
+	PlatformVersion string    `json:"platformVersion"`
+	KernelVersion   string    `json:"kernelVersion"`
+	Arch            string    `json:"arch"`
+	BootTime        time.Time `json:"bootTime"`
+	CPUModel        string    `json:"cpuModel"`
+	PhysicalCores   int       `json:"physicalCores"`
+	LogicalCores    int       `json:"logicalCores"`
+	AgentVersion    string    `json:"agentVersion"`
+}
+
+// CollectHost reads the host inventory. Fields gopsutil can't determine on
+// this platform are left empty rather than failing the whole collection.
+func CollectHost(agentVersion string) (Host, error) {
+	h := Host{
+		OS:           runtime.GOOS,
+		Arch:         runtime.GOARCH,
+		LogicalCores: runtime.NumCPU(),
+		AgentVersion: agentVersion,
+	}
+
+	info, err := host.Info()
+	if err != nil {
+		return h, fmt.Errorf("error reading host info: %v", err)
+	}
+	h.Platform = info.Platform
+	h.PlatformFamily = info.PlatformFamily
+	h.PlatformVersion = info.PlatformVersion
+	h.KernelVersion = info.KernelVersion
+	if info.KernelArch != "" {
+		h.Arch = info.KernelArch
+	}
+	h.BootTime = time.Unix(int64(info.BootTime), 0).UTC()
+
+	if cpus, err := cpu.Info(); err == nil && len(cpus) > 0 {
+		h.CPUModel = cpus[0].ModelName
+	}
+	if n, err := cpu.Counts(false); err == nil && n > 0 {
+		h.PhysicalCores = n
+	}
+	if n, err := cpu.Counts(true); err == nil && n > 0 {
+		h.LogicalCores = n
+	}
+	return h, nil
+}
+
+// Uptime is how long the host has been up at now.
+func (h Host) Uptime(now time.Time) time.Duration {
+	if h.BootTime.IsZero() {
+		return 0
+	}
+	return now.Sub(h.BootTime).Truncate(time.Second)
+}
+
+// OSName is the coarse operating system name reported in osName.
+func (h Host) OSName() string {
+	switch h.OS {
+	case "windows":
+		return "Windows"
+	case "linux":
+		return "Linux"
+	case "darwin":
+		return "macOS"
+	default:
+		return h.OS
+	}
+}
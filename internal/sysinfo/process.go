@@ -0,0 +1,164 @@
+package sysinfo
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// Process sort orders.
+const (
+	SortCPU    = "cpu"
+	SortMemory = "memory"
+)
+
+// Command line reporting modes.
+const (
+	CmdlineFull     = "full"
+	CmdlineRedacted = "redacted"
+	CmdlineNone     = "none"
+)
+
+// Process is one entry of a process snapshot. CPUPercent is measured over
+// the sampling window and, like top, is relative to a single core.
+type Process struct {
+	PID        int32     `json:"pid"`
+	Name       string    `json:"name"`
+	User       string    `json:"user,omitempty"`
+	CPUPercent float64   `json:"cpuPercent"`
+	RSS        uint64    `json:"rss"`
+	StartTime  time.Time `json:"startTime"`
+	Cmdline    string    `json:"cmdline,omitempty"`
+}
+
+// ProcessOptions control TopProcesses.
+type ProcessOptions struct {
+	Limit   int
+	SortBy  string
+	Cmdline string
+	Window  time.Duration
+}
+
+// TopProcesses returns the Limit busiest processes by CPU or resident
+// memory. Processes that exit or can't be read during the window are
+// skipped.
+func TopProcesses(ctx context.Context, opts ProcessOptions) ([]Process, error) {
+	if opts.SortBy != SortCPU && opts.SortBy != SortMemory {
+		return nil, fmt.Errorf("unknown sort order %q", opts.SortBy)
+	}
+	procs, err := process.ProcessesWithContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error listing processes: %v", err)
+	}
+
+	before := make(map[int32]float64, len(procs))
+	for _, p := range procs {
+		if t, err := p.TimesWithContext(ctx); err == nil {
+			before[p.Pid] = t.User + t.System
+		}
+	}
+	start := time.Now()
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(opts.Window):
+	}
+	elapsed := time.Since(start).Seconds()
+
+	type sample struct {
+		proc *process.Process
+		cpu  float64
+		rss  uint64
+	}
+	samples := make([]sample, 0, len(procs))
+	for _, p := range procs {
+		t, err := p.TimesWithContext(ctx)
+		if err != nil {
+			continue
+		}
+		mem, err := p.MemoryInfoWithContext(ctx)
+		if err != nil {
+			continue
+		}
+		s := sample{proc: p, rss: mem.RSS}
+		if prev, ok := before[p.Pid]; ok && elapsed > 0 {
+			s.cpu = max(t.User+t.System-prev, 0) / elapsed * 100
+		}
+		samples = append(samples, s)
+	}
+
+	sort.Slice(samples, func(i, j int) bool {
+		a, b := samples[i], samples[j]
+		if opts.SortBy == SortMemory && a.rss != b.rss {
+			return a.rss > b.rss
+		}
+		if a.cpu != b.cpu {
+			return a.cpu > b.cpu
+		}
+		return a.rss > b.rss
+	})
+	if opts.Limit > 0 && len(samples) > opts.Limit {
+		samples = samples[:opts.Limit]
+	}
+
+	out := make([]Process, 0, len(samples))
+	for _, s := range samples {
+		p := Process{PID: s.proc.Pid, CPUPercent: s.cpu, RSS: s.rss}
+		p.Name, _ = s.proc.NameWithContext(ctx)
+		p.User, _ = s.proc.UsernameWithContext(ctx)
+		if ms, err := s.proc.CreateTimeWithContext(ctx); err == nil {
+			p.StartTime = time.UnixMilli(ms).UTC()
+		}
+		if opts.Cmdline != CmdlineNone {
+			p.Cmdline, _ = s.proc.CmdlineWithContext(ctx)
+			if opts.Cmdline != CmdlineFull {
+				p.Cmdline = RedactCmdline(p.Cmdline)
+			}
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+// secretWords mark arguments whose values are masked by RedactCmdline.
+var secretWords = []string{"pass", "pwd", "secret", "token", "apikey", "api-key", "api_key", "auth", "credential", "private-key", "private_key"}
+
+var urlCredentials = regexp.MustCompile(`(://[^:/@\s]+):[^@\s]+@`)
+
+// RedactCmdline masks the values of password, token and similar arguments,
+// in "--name=value", "name=value" and "--name value" form, and passwords in
+// URLs. Arguments are matched by name only: short flags such as "-p secret"
+// or "-psecret" and positional secrets are not masked, so use CmdlineNone
+// where those matter. Runs of whitespace are collapsed to single spaces.
+func RedactCmdline(cmdline string) string {
+	fields := strings.Fields(cmdline)
+	for i := 0; i < len(fields); i++ {
+		f := fields[i]
+		if name, _, ok := strings.Cut(f, "="); ok {
+			if isSecret(name) {
+				fields[i] = name + "=***"
+			}
+			continue
+		}
+		if strings.HasPrefix(f, "-") && isSecret(f) && i+1 < len(fields) && !strings.HasPrefix(fields[i+1], "-") {
+			fields[i+1] = "***"
+			i++
+		}
+	}
+	return urlCredentials.ReplaceAllString(strings.Join(fields, " "), "$1:***@")
+}
+
+func isSecret(name string) bool {
+	name = strings.ToLower(strings.TrimLeft(name, "-/"))
+	for _, w := range secretWords {
+		if strings.Contains(name, w) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,150 @@
+package sysinfo
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"runtime"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/load"
+	psnet "github.com/shirou/gopsutil/v3/net"
+)
+
+// Usage is how busy the host was over a short sampling window.
+type Usage struct {
+	Window  time.Duration `json:"window"`
+	CPU     CPU           `json:"cpu"`
+	Load    *Load         `json:"load,omitempty"`
+	Network []Interface   `json:"network"`
+}
+
+// CPU utilisation in percent, overall and per logical core.
+type CPU struct {
+	Percent float64   `json:"percent"`
+	PerCore []float64 `json:"perCore"`
+}
+
+// Load holds the 1, 5 and 15 minute load averages. Windows has none.
+type Load struct {
+	Load1  float64 `json:"load1"`
+	Load5  float64 `json:"load5"`
+	Load15 float64 `json:"load15"`
+}
+
+// Interface is the traffic of one network interface during the window, plus
+// its byte counters since boot.
+type Interface struct {
+	Name          string  `json:"name"`
+	RxBytesPerSec float64 `json:"rxBytesPerSec"`
+	TxBytesPerSec float64 `json:"txBytesPerSec"`
+	RxBytes       uint64  `json:"rxBytes"`
+	TxBytes       uint64  `json:"txBytes"`
+}
+
+// SampleUsage measures CPU and network activity over window. Load averages
+// are read where the platform has them.
+func SampleUsage(ctx context.Context, window time.Duration) (Usage, error) {
+	cpuBefore, err := cpu.TimesWithContext(ctx, true)
+	if err != nil {
+		return Usage{}, fmt.Errorf("error reading CPU times: %v", err)
+	}
+	netBefore, err := psnet.IOCountersWithContext(ctx, true)
+	if err != nil {
+		return Usage{}, fmt.Errorf("error reading network counters: %v", err)
+	}
+	start := time.Now()
+
+	select {
+	case <-ctx.Done():
+		return Usage{}, ctx.Err()
+	case <-time.After(window):
+	}
+
+	cpuAfter, err := cpu.TimesWithContext(ctx, true)
+	if err != nil {
+		return Usage{}, fmt.Errorf("error reading CPU times: %v", err)
+	}
+	netAfter, err := psnet.IOCountersWithContext(ctx, true)
+	if err != nil {
+		return Usage{}, fmt.Errorf("error reading network counters: %v", err)
+	}
+	elapsed := time.Since(start)
+
+	u := Usage{Window: elapsed, CPU: cpuUsage(cpuBefore, cpuAfter)}
+	u.Network = networkUsage(netBefore, netAfter, elapsed)
+	if runtime.GOOS != "windows" {
+		if avg, err := load.AvgWithContext(ctx); err == nil {
+			u.Load = &Load{Load1: avg.Load1, Load5: avg.Load5, Load15: avg.Load15}
+		}
+	}
+	return u, nil
+}
+
+func cpuUsage(before, after []cpu.TimesStat) CPU {
+	c := CPU{PerCore: make([]float64, 0, len(after))}
+	var busy, total float64
+	for i := 0; i < len(before) && i < len(after); i++ {
+		b, t := busyTime(after[i])
+		b0, t0 := busyTime(before[i])
+		c.PerCore = append(c.PerCore, percent(b-b0, t-t0))
+		busy += b - b0
+		total += t - t0
+	}
+	c.Percent = percent(busy, total)
+	return c
+}
+
+// busyTime returns the non-idle and total CPU time. Guest time is already
+// counted in user time, so it's left out of the total.
+func busyTime(t cpu.TimesStat) (busy, total float64) {
+	total = t.User + t.System + t.Idle + t.Nice + t.Iowait + t.Irq + t.Softirq + t.Steal
+	return total - t.Idle - t.Iowait, total
+}
+
+func percent(part, whole float64) float64 {
+	if whole <= 0 {
+		return 0
+	}
+	return min(max(part/whole*100, 0), 100)
+}
+
+func networkUsage(before, after []psnet.IOCountersStat, elapsed time.Duration) []Interface {
+	prev := make(map[string]psnet.IOCountersStat, len(before))
+	for _, c := range before {
+		prev[c.Name] = c
+	}
+	secs := elapsed.Seconds()
+	out := []Interface{}
+	for _, c := range after {
+		if isLoopback(c.Name) {
+			continue
+		}
+		p, ok := prev[c.Name]
+		if !ok || secs <= 0 {
+			continue
+		}
+		out = append(out, Interface{
+			Name:          c.Name,
+			RxBytesPerSec: rate(p.BytesRecv, c.BytesRecv, secs),
+			TxBytesPerSec: rate(p.BytesSent, c.BytesSent, secs),
+			RxBytes:       c.BytesRecv,
+			TxBytes:       c.BytesSent,
+		})
+	}
+	return out
+}
+
+// rate tolerates counters that were reset between the two readings.
+func rate(before, after uint64, secs float64) float64 {
+	if after < before {
+		return 0
+	}
+	return float64(after-before) / secs
+}
+
+func isLoopback(name string) bool {
+	iface, err := net.InterfaceByName(name)
+	return err == nil && iface.Flags&net.FlagLoopback != 0
+}
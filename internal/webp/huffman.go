@@ -0,0 +1,245 @@
+package webp
+
+// prefixCode is a canonical Huffman code over one VP8L alphabet.
+type prefixCode struct {
+	lengths []uint8
+	codes   []uint32
+	// used holds the symbols with a non-zero count, in ascending order.
+	used []int
+}
+
+func newPrefixCode(counts []uint32, limit int) *prefixCode {
+	c := &prefixCode{lengths: codeLengths(counts, limit)}
+	for s, l := range c.lengths {
+		if l > 0 {
+			c.used = append(c.used, s)
+		}
+	}
+	c.codes = canonicalCodes(c.lengths)
+	return c
+}
+
+// codeLengths builds Huffman code lengths for counts, no longer than limit.
+// When the tree is too deep the counts are flattened and the tree rebuilt,
+// which converges on a balanced tree.
+func codeLengths(counts []uint32, limit int) []uint8 {
+	counts = append([]uint32(nil), counts...)
+	for {
+		lengths, depth := buildTree(counts)
+		if depth <= limit {
+			return lengths
+		}
+		for i, n := range counts {
+			if n > 0 {
+				counts[i] = n/2 + 1
+			}
+		}
+	}
+}
+
+func buildTree(counts []uint32) ([]uint8, int) {
+	type node struct {
+		weight uint64
+		parent int
+	}
+	lengths := make([]uint8, len(counts))
+	var nodes []node
+	var leaves []int
+	for s, n := range counts {
+		if n > 0 {
+			nodes = append(nodes, node{weight: uint64(n), parent: -1})
+			leaves = append(leaves, s)
+		}
+	}
+	switch len(leaves) {
+	case 0:
+		return lengths, 0
+	case 1:
+		lengths[leaves[0]] = 1
+		return lengths, 1
+	}
+
+	// Repeatedly join the two lightest roots. Alphabets are at most a few
+	// hundred symbols, so a linear scan is cheap enough.
+	active := make([]int, len(nodes))
+	for i := range active {
+		active[i] = i
+	}
+	for len(active) > 1 {
+		a, b := 0, 1
+		if nodes[active[b]].weight < nodes[active[a]].weight {
+			a, b = b, a
+		}
+		for i := 2; i < len(active); i++ {
+			w := nodes[active[i]].weight
+			if w < nodes[active[a]].weight {
+				a, b = i, a
+			} else if w < nodes[active[b]].weight {
+				b = i
+			}
+		}
+		parent := len(nodes)
+		nodes = append(nodes, node{weight: nodes[active[a]].weight + nodes[active[b]].weight, parent: -1})
+		nodes[active[a]].parent = parent
+		nodes[active[b]].parent = parent
+		if a > b {
+			a, b = b, a
+		}
+		active[a] = parent
+		active = append(active[:b], active[b+1:]...)
+	}
+
+	maxDepth := 0
+	for i, s := range leaves {
+		depth := 0
+		for n := i; nodes[n].parent >= 0; n = nodes[n].parent {
+			depth++
+		}
+		lengths[s] = uint8(depth)
+		if depth > maxDepth {
+			maxDepth = depth
+		}
+	}
+	return lengths, maxDepth
+}
+
+// canonicalCodes assigns codes the same way the decoder rebuilds them from
+// code lengths alone.
+func canonicalCodes(lengths []uint8) []uint32 {
+	var count [maxCodeLength + 1]uint32
+	for _, l := range lengths {
+		count[l]++
+	}
+	count[0] = 0
+	var next [maxCodeLength + 1]uint32
+	code := uint32(0)
+	for l := 1; l <= maxCodeLength; l++ {
+		code = (code + count[l-1]) << 1
+		next[l] = code
+	}
+	codes := make([]uint32, len(lengths))
+	for s, l := range lengths {
+		if l > 0 {
+			codes[s] = next[l]
+			next[l]++
+		}
+	}
+	return codes
+}
+
+// writeSymbol emits the code for s. Codes are written most significant bit
+// first. A code with a single symbol takes no bits at all.
+func (c *prefixCode) writeSymbol(w *bitWriter, s int) {
+	if len(c.used) <= 1 {
+		return
+	}
+	code, n := c.codes[s], uint(c.lengths[s])
+	for i := int(n) - 1; i >= 0; i-- {
+		w.writeBits(code>>uint(i)&1, 1)
+	}
+}
+
+// writeTo writes the code definition. One or two small symbols use the
+// simple form, everything else is sent as run-length coded code lengths.
+func (c *prefixCode) writeTo(w *bitWriter) {
+	if len(c.used) <= 2 && (len(c.used) == 0 || c.used[len(c.used)-1] < 256) {
+		w.writeBits(1, 1)
+		if len(c.used) == 0 {
+			w.writeBits(0, 1)
+			w.writeBits(0, 1)
+			w.writeBits(0, 1)
+			return
+		}
+		w.writeBits(uint32(len(c.used)-1), 1)
+		if first := c.used[0]; first < 2 {
+			w.writeBits(0, 1)
+			w.writeBits(uint32(first), 1)
+		} else {
+			w.writeBits(1, 1)
+			w.writeBits(uint32(first), 8)
+		}
+		if len(c.used) == 2 {
+			w.writeBits(uint32(c.used[1]), 8)
+		}
+		return
+	}
+
+	w.writeBits(0, 1)
+	symbols, extras := runLengths(c.lengths)
+	var counts [19]uint32
+	for _, s := range symbols {
+		counts[s]++
+	}
+	lengthCode := newPrefixCode(counts[:], maxCodeLengthLength)
+
+	n := 4
+	for i := len(codeLengthOrder) - 1; i >= 4; i-- {
+		if lengthCode.lengths[codeLengthOrder[i]] > 0 {
+			n = i + 1
+			break
+		}
+	}
+	w.writeBits(uint32(n-4), 4)
+	for i := 0; i < n; i++ {
+		w.writeBits(uint32(lengthCode.lengths[codeLengthOrder[i]]), 3)
+	}
+
+	w.writeBits(0, 1) // code lengths cover the whole alphabet
+	for i, s := range symbols {
+		lengthCode.writeSymbol(w, s)
+		switch s {
+		case 16:
+			w.writeBits(extras[i], 2)
+		case 17:
+			w.writeBits(extras[i], 3)
+		case 18:
+			w.writeBits(extras[i], 7)
+		}
+	}
+}
+
+// runLengths encodes code lengths with the VP8L code length alphabet: 0-15
+// are literal lengths, 16 repeats the previous length 3-6 times, 17 and 18
+// write runs of 3-10 and 11-138 zeros.
+func runLengths(lengths []uint8) (symbols []int, extras []uint32) {
+	emit := func(s int, extra uint32) {
+		symbols = append(symbols, s)
+		extras = append(extras, extra)
+	}
+	for i := 0; i < len(lengths); {
+		l := lengths[i]
+		run := 1
+		for i+run < len(lengths) && lengths[i+run] == l {
+			run++
+		}
+		i += run
+
+		if l == 0 {
+			for run >= 11 {
+				n := min(run, 138)
+				emit(18, uint32(n-11))
+				run -= n
+			}
+			if run >= 3 {
+				emit(17, uint32(run-3))
+				run = 0
+			}
+			for ; run > 0; run-- {
+				emit(0, 0)
+			}
+			continue
+		}
+
+		emit(int(l), 0)
+		run--
+		for run >= 3 {
+			n := min(run, 6)
+			emit(16, uint32(n-3))
+			run -= n
+		}
+		for ; run > 0; run-- {
+			emit(int(l), 0)
+		}
+	}
+	return symbols, extras
+}
@@ -0,0 +1,228 @@
+// Package webp implements a lossless WebP (VP8L) encoder.
+//
+// The encoder keeps to the simple end of the format: a subtract-green
+// transform, a single set of prefix codes, and LZ77 references to the pixel
+// on the left and the pixel above. That covers the long runs and repeated
+// rows that make up most screenshots without the cost of a full match finder.
+package webp
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/draw"
+	"io"
+	"math/bits"
+)
+
+const (
+	maxDimension = 1 << 14
+
+	numLiteralCodes  = 256
+	numLengthCodes   = 24
+	numDistanceCodes = 40
+	maxLength        = 4096
+	minMatch         = 3
+
+	transformSubtractGreen = 2
+
+	// Distance codes 1 and 2 map to the pixel above and the pixel to the
+	// left in the VP8L distance table.
+	distanceAbove = 1
+	distanceLeft  = 2
+
+	maxCodeLength       = 15
+	maxCodeLengthLength = 7
+)
+
+// codeLengthOrder is the order code length code lengths are written in.
+var codeLengthOrder = [19]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// token is either a literal pixel or a backward reference.
+type token struct {
+	argb     uint32
+	length   uint32
+	distance uint32
+}
+
+// Encode writes img to w as a lossless WebP image.
+func Encode(w io.Writer, img image.Image) error {
+	b := img.Bounds()
+	width, height := b.Dx(), b.Dy()
+	if width <= 0 || height <= 0 || width > maxDimension || height > maxDimension {
+		return fmt.Errorf("webp: invalid image size %dx%d", width, height)
+	}
+
+	nrgba, ok := img.(*image.NRGBA)
+	if !ok || nrgba.Rect.Min != (image.Point{}) {
+		nrgba = image.NewNRGBA(image.Rect(0, 0, width, height))
+		draw.Draw(nrgba, nrgba.Bounds(), img, b.Min, draw.Src)
+	}
+
+	argb := make([]uint32, width*height)
+	hasAlpha := false
+	for y := 0; y < height; y++ {
+		row := nrgba.Pix[y*nrgba.Stride : y*nrgba.Stride+4*width]
+		for x := 0; x < width; x++ {
+			r, g, b, a := row[4*x], row[4*x+1], row[4*x+2], row[4*x+3]
+			if a != 0xff {
+				hasAlpha = true
+			}
+			// Subtract green: red and blue are stored relative to green.
+			argb[y*width+x] = uint32(a)<<24 | uint32(r-g)<<16 | uint32(g)<<8 | uint32(b-g)
+		}
+	}
+
+	tokens := tokenize(argb, width)
+
+	var green [numLiteralCodes + numLengthCodes]uint32
+	var red, blue, alpha [256]uint32
+	var dist [numDistanceCodes]uint32
+	for _, t := range tokens {
+		if t.length == 0 {
+			green[t.argb>>8&0xff]++
+			red[t.argb>>16&0xff]++
+			blue[t.argb&0xff]++
+			alpha[t.argb>>24]++
+			continue
+		}
+		lc, _, _ := prefixEncode(t.length)
+		green[numLiteralCodes+lc]++
+		dc, _, _ := prefixEncode(t.distance)
+		dist[dc]++
+	}
+
+	bw := &bitWriter{}
+	bw.writeBits(0x2f, 8)
+	bw.writeBits(uint32(width-1), 14)
+	bw.writeBits(uint32(height-1), 14)
+	if hasAlpha {
+		bw.writeBits(1, 1)
+	} else {
+		bw.writeBits(0, 1)
+	}
+	bw.writeBits(0, 3) // version
+
+	bw.writeBits(1, 1) // transform present
+	bw.writeBits(transformSubtractGreen, 2)
+	bw.writeBits(0, 1) // no more transforms
+
+	bw.writeBits(0, 1) // no color cache
+	bw.writeBits(0, 1) // single prefix code group
+
+	codes := [5]*prefixCode{
+		newPrefixCode(green[:], maxCodeLength),
+		newPrefixCode(red[:], maxCodeLength),
+		newPrefixCode(blue[:], maxCodeLength),
+		newPrefixCode(alpha[:], maxCodeLength),
+		newPrefixCode(dist[:], maxCodeLength),
+	}
+	for _, c := range codes {
+		c.writeTo(bw)
+	}
+
+	for _, t := range tokens {
+		if t.length == 0 {
+			codes[0].writeSymbol(bw, int(t.argb>>8&0xff))
+			codes[1].writeSymbol(bw, int(t.argb>>16&0xff))
+			codes[2].writeSymbol(bw, int(t.argb&0xff))
+			codes[3].writeSymbol(bw, int(t.argb>>24))
+			continue
+		}
+		lc, lbits, lextra := prefixEncode(t.length)
+		codes[0].writeSymbol(bw, numLiteralCodes+lc)
+		bw.writeBits(lextra, lbits)
+		dc, dbits, dextra := prefixEncode(t.distance)
+		codes[4].writeSymbol(bw, dc)
+		bw.writeBits(dextra, dbits)
+	}
+	data := bw.bytes()
+
+	chunkLen := len(data)
+	padded := chunkLen + chunkLen&1
+	header := make([]byte, 20)
+	copy(header[0:], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], uint32(4+8+padded))
+	copy(header[8:], "WEBPVP8L")
+	binary.LittleEndian.PutUint32(header[16:], uint32(chunkLen))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if chunkLen&1 == 1 {
+		data = append(data, 0)
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// tokenize greedily replaces pixels with references to the left or upper
+// neighbour whenever that covers at least minMatch pixels.
+func tokenize(argb []uint32, width int) []token {
+	tokens := make([]token, 0, len(argb)/4)
+	for p := 0; p < len(argb); {
+		bestLen, bestDist := 0, uint32(0)
+		if p >= 1 {
+			if n := matchLength(argb, p, 1); n > bestLen {
+				bestLen, bestDist = n, distanceLeft
+			}
+		}
+		if p >= width {
+			if n := matchLength(argb, p, width); n > bestLen {
+				bestLen, bestDist = n, distanceAbove
+			}
+		}
+		if bestLen >= minMatch {
+			tokens = append(tokens, token{length: uint32(bestLen), distance: bestDist})
+			p += bestLen
+			continue
+		}
+		tokens = append(tokens, token{argb: argb[p]})
+		p++
+	}
+	return tokens
+}
+
+func matchLength(argb []uint32, p, dist int) int {
+	n := 0
+	for p+n < len(argb) && n < maxLength && argb[p+n] == argb[p+n-dist] {
+		n++
+	}
+	return n
+}
+
+// prefixEncode splits a length or distance into its prefix symbol and the
+// extra bits that follow it.
+func prefixEncode(v uint32) (code int, extraBits uint, extra uint32) {
+	if v <= 4 {
+		return int(v - 1), 0, 0
+	}
+	v--
+	high := uint(bits.Len32(v) - 1)
+	second := (v >> (high - 1)) & 1
+	extraBits = high - 1
+	return int(2*high + uint(second)), extraBits, v & (1<<extraBits - 1)
+}
+
+type bitWriter struct {
+	buf   []byte
+	acc   uint64
+	nbits uint
+}
+
+func (w *bitWriter) writeBits(v uint32, n uint) {
+	w.acc |= uint64(v) << w.nbits
+	w.nbits += n
+	for w.nbits >= 8 {
+		w.buf = append(w.buf, byte(w.acc))
+		w.acc >>= 8
+		w.nbits -= 8
+	}
+}
+
+func (w *bitWriter) bytes() []byte {
+	if w.nbits > 0 {
+		w.buf = append(w.buf, byte(w.acc))
+		w.acc, w.nbits = 0, 0
+	}
+	return w.buf
+}
@@ -0,0 +1,129 @@
+package webp
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+
+	xwebp "golang.org/x/image/webp"
+)
+
+func gradient(r image.Rectangle) *image.NRGBA {
+	img := image.NewNRGBA(r)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: uint8(x + y), A: 0xff})
+		}
+	}
+	return img
+}
+
+func noise(r image.Rectangle, alpha bool) *image.NRGBA {
+	rng := rand.New(rand.NewSource(1))
+	img := image.NewNRGBA(r)
+	rng.Read(img.Pix)
+	if !alpha {
+		for i := 3; i < len(img.Pix); i += 4 {
+			img.Pix[i] = 0xff
+		}
+	}
+	return img
+}
+
+func solid(r image.Rectangle, c color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(r)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// screenshot mimics a desktop: flat areas, repeated rows and some detail.
+func screenshot(r image.Rectangle) *image.NRGBA {
+	img := solid(r, color.NRGBA{R: 0xf0, G: 0xf0, B: 0xf0, A: 0xff})
+	detail := noise(image.Rect(0, 0, r.Dx()/4, r.Dy()/4), false)
+	for y := 0; y < detail.Rect.Dy(); y++ {
+		copy(img.Pix[(y+10)*img.Stride+40:], detail.Pix[y*detail.Stride:(y+1)*detail.Stride])
+	}
+	return img
+}
+
+// skewed uses green values with Fibonacci frequencies, whose Huffman tree is
+// deeper than the 15 bits VP8L allows, so code lengths have to be limited.
+func skewed() *image.NRGBA {
+	var values []uint8
+	a, b := 1, 1
+	for v := 0; v < 24; v++ {
+		for i := 0; i < a; i++ {
+			values = append(values, uint8(v*10))
+		}
+		a, b = b, a+b
+	}
+	rand.New(rand.NewSource(1)).Shuffle(len(values), func(i, j int) { values[i], values[j] = values[j], values[i] })
+	width := 512
+	img := image.NewNRGBA(image.Rect(0, 0, width, (len(values)+width-1)/width))
+	for i, v := range values {
+		img.SetNRGBA(i%width, i/width, color.NRGBA{G: v, A: 0xff})
+	}
+	return img
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		img  image.Image
+	}{
+		{"gradient", gradient(image.Rect(0, 0, 300, 200))},
+		{"noise", noise(image.Rect(0, 0, 129, 67), false)},
+		{"noise with alpha", noise(image.Rect(0, 0, 64, 64), true)},
+		{"1x1", solid(image.Rect(0, 0, 1, 1), color.NRGBA{R: 1, G: 2, B: 3, A: 0xff})},
+		{"solid", solid(image.Rect(0, 0, 320, 240), color.NRGBA{R: 0x20, G: 0x40, B: 0x80, A: 0xff})},
+		{"non-zero origin", gradient(image.Rect(-30, 40, 70, 90))},
+		{"screenshot", screenshot(image.Rect(0, 0, 400, 300))},
+		{"deep codes", skewed()},
+		{"maximum width", gradient(image.Rect(0, 0, maxDimension, 2))},
+		{"transparent rgba", image.NewRGBA(image.Rect(0, 0, 16, 16))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Encode(&buf, tt.img); err != nil {
+				t.Fatal(err)
+			}
+			got, err := xwebp.Decode(&buf)
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+
+			b := tt.img.Bounds()
+			if got.Bounds().Size() != b.Size() {
+				t.Fatalf("decoded size %v, want %v", got.Bounds().Size(), b.Size())
+			}
+			gb := got.Bounds()
+			for y := 0; y < b.Dy(); y++ {
+				for x := 0; x < b.Dx(); x++ {
+					want := color.NRGBAModel.Convert(tt.img.At(b.Min.X+x, b.Min.Y+y))
+					have := color.NRGBAModel.Convert(got.At(gb.Min.X+x, gb.Min.Y+y))
+					if have != want {
+						t.Fatalf("pixel (%d,%d) = %v, want %v", x, y, have, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestEncodeInvalidSize(t *testing.T) {
+	for _, r := range []image.Rectangle{
+		image.Rect(0, 0, 0, 10),
+		image.Rect(0, 0, maxDimension+1, 1),
+	} {
+		if err := Encode(&bytes.Buffer{}, image.NewNRGBA(r)); err == nil {
+			t.Errorf("Encode of a %v image succeeded", r.Size())
+		}
+	}
+}
@@ -3,48 +3,99 @@ package main
 import (
 	"bytes"
 	"context"
-	"crypto/tls"
-	_ "embed"
+	"embed"
 
 	"encoding/json"
+	"errors"
 	"fmt"
-	"image/jpeg"
+	"io/fs"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
-	"strings"
+	"path/filepath"
+	"runtime"
+	"sync"
 	"syscall"
 	"time"
 
 	pb "capture-screen/src/output"
 
 	"capture-screen/internal/aws"
+	"capture-screen/internal/capture"
+	"capture-screen/internal/command"
 	"capture-screen/internal/config"
+	"capture-screen/internal/grpcclient"
+	"capture-screen/internal/schedule"
+	"capture-screen/internal/storage"
+	"capture-screen/internal/sysinfo"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/gosimple/slug"
 	"github.com/joho/godotenv"
-	"github.com/kbinani/screenshot"
 	"github.com/shirou/gopsutil/v3/disk"
 	"github.com/shirou/gopsutil/v3/mem"
-	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
 )
 
 type Response struct {
-	DeviceName  string `json:"deviceName"`
-	Timestamp   string `json:"timestamp"`
-	OSName      string `json:"osName"`
-	MemoryUsage string `json:"memoryUsage"`
-	DiskUsage   string `json:"diskUsage"`
-	LastImage   string `json:"lastImage"`
+	DeviceName   string                `json:"deviceName"`
+	Timestamp    string                `json:"timestamp"`
+	OSName       string                `json:"osName"`
+	MemoryUsage  string                `json:"memoryUsage"`
+	DiskUsage    string                `json:"diskUsage"`
+	LastImage    string                `json:"lastImage"`
+	ThumbnailURL string                `json:"thumbnailUrl,omitempty"`
+	Displays     []command.DisplayInfo `json:"displays,omitempty"`
+	Region       *command.RegionInfo   `json:"region,omitempty"`
+	Memory       ResourceUsage         `json:"memory"`
+	Disk         ResourceUsage         `json:"disk"`
+	Host         sysinfo.Host          `json:"host"`
+	Uptime       time.Duration         `json:"uptime"`
+	Usage        *sysinfo.Usage        `json:"usage,omitempty"`
+	Volumes      []sysinfo.Volume      `json:"volumes"`
+	VolumesFull  bool                  `json:"volumesFull"`
+	// Unchanged marks a change-detection capture that was not uploaded
+	// because it matched the previous one; the image fields then refer to
+	// that earlier capture.
+	Unchanged     bool    `json:"unchanged,omitempty"`
+	ChangePercent float64 `json:"changePercent,omitempty"`
 }
 
+// ResourceUsage is the raw form of MemoryUsage and DiskUsage.
+type ResourceUsage struct {
+	Used        uint64  `json:"used"`
+	Total       uint64  `json:"total"`
+	UsedPercent float64 `json:"usedPercent"`
+}
+
+func (u ResourceUsage) String() string {
+	return fmt.Sprintf("%v / %v", formatBytes(u.Used), formatBytes(u.Total))
+}
+
+// version is the agent version, set at build time with
+// -ldflags "-X main.version=<version>".
+var version = "dev"
+
 var (
 	deviceName string
 	osName     string
-	s3Service  *aws.S3Service
+	hostInfo   sysinfo.Host
+	captures   *storage.Captures
+	capturer   capture.Capturer
+	replier    *command.Replier
+	scheduler  *schedule.Scheduler
+	grpcClient *grpcclient.Client
+
+	defaultEncoding capture.Encoding
+	defaultSizing   capture.Sizing
+
+	usageSampleWindow time.Duration
+	volumeFilter      sysinfo.VolumeFilter
+	processLimit      int
+	processCmdline    string
+	changeThreshold   float64
 )
 
 type MessageType int
@@ -58,6 +109,15 @@ var certPEM []byte
 //go:embed internal/certs/privkey1.pem
 var keyPEM []byte
 
+// certFiles holds an optional CA bundle, internal/certs/ca.pem, used when
+// GRPC_CA_SOURCE is "embedded".
+//
+//go:embed internal/certs
+var certFiles embed.FS
+
+// maxProcessLimit caps how many processes one list-processes command returns.
+const maxProcessLimit = 100
+
 const (
 	CAPTURE_SCREEN MessageType = iota
 	PING_DEVICE
@@ -69,8 +129,11 @@ func init() {
 	if err != nil {
 		deviceName = "unknown"
 	}
-	osName = "Windows" // Or use runtime.GOOS for dynamic OS detection
-
+	hostInfo, err = sysinfo.CollectHost(version)
+	if err != nil {
+		log.Printf("Incomplete host inventory: %v", err)
+	}
+	osName = hostInfo.OSName()
 }
 
 func SubscribeRedis(channelName string, redisClient redis.Client) {
@@ -82,42 +145,217 @@ func SubscribeRedis(channelName string, redisClient redis.Client) {
 		// Process message in a goroutine to handle multiple messages concurrently
 		go func(message *redis.Message) {
 			log.Printf("Received message from channel %s: %s\n", message.Channel, message.Payload)
-			switch message.Payload {
-			case "capture-screen-" + getSlugDeviceName():
-				response, err := getSystemInfo("capture-screen")
-				if err != nil {
-					log.Printf("Error getting system info: %v", err)
-					return
-				}
+			processCommand(message.Payload)
+		}(msg)
+	}
+}
 
-				sendGRPCCall(response, int32(CAPTURE_SCREEN))
-				break
-			case "scan-devices":
-				log.Println("Scanning devices")
-				deviceName := getDeviceName()
-				log.Println("Device Name:", deviceName)
-				sendHTTPCall(map[string]interface{}{"deviceName": deviceName}, "/return-device-name")
-				break
-			case "ping-device-" + getSlugDeviceName():
-				log.Println("Pinging device")
-				response, err := getSystemInfo("ping-device")
-				if err != nil {
-					log.Printf("Error getting system info: %v", err)
-					return
-				}
-				sendGRPCCall(response, int32(PING_DEVICE))
-				break
-			default:
-				log.Println("Unknown command", message.Payload)
+// subscribeGRPC receives commands over the gRPC command stream instead of
+// Redis until ctx is cancelled.
+func subscribeGRPC(ctx context.Context, stream *grpcclient.CommandStream) {
+	stream.Run(ctx, func(payload string) {
+		log.Printf("Received message from command stream: %s\n", payload)
+		processCommand(payload)
+	})
+}
+
+func processCommand(payload string) {
+	env, err := command.Parse(payload)
+	if err != nil {
+		rejectCommand(nil, err)
+		return
+	}
+	// Commands for other devices arrive on shared channels too. Replying
+	// would bury the target's own reply, so they are dropped silently.
+	if !env.TargetsDevice(getSlugDeviceName()) {
+		log.Printf("Ignoring command %s for device %q", env.ID, env.Target)
+		return
+	}
+	handleCommand(env)
+}
+
+func handleCommand(env *command.Envelope) {
+	start := time.Now()
+	if err := replier.Ack(context.Background(), env); err != nil {
+		log.Printf("Error publishing ack for command %s: %v", env.ID, err)
+	}
+
+	var res command.Result
+	err := runCommand(env, &res)
+	res.Duration = time.Since(start)
+	if err != nil {
+		log.Printf("Command %s (%s) failed: %v", env.ID, env.Type, err)
+		res.ErrorCode = command.ErrorCode(err)
+		res.ErrorMessage = err.Error()
+	} else {
+		res.Success = true
+	}
+
+	if err := replier.Result(context.Background(), env, res); err != nil {
+		log.Printf("Error publishing result for command %s: %v", env.ID, err)
+	}
+}
+
+func runCommand(env *command.Envelope, res *command.Result) error {
+	switch env.Type {
+	case command.TypeCaptureScreen:
+		args, err := captureArgs(env)
+		if err != nil {
+			return err
+		}
+		response, err := getSystemInfo(capturer, "capture-screen", args)
+		if err != nil {
+			return err
+		}
+		res.ImageURL = response.LastImage
+		res.ThumbnailURL = response.ThumbnailURL
+		res.Displays = response.Displays
+		res.Region = response.Region
+		res.Unchanged = response.Unchanged
+		res.ChangePct = response.ChangePercent
+
+		return sendGRPCCall(response, int32(CAPTURE_SCREEN))
+	case command.TypeScanDevices:
+		log.Println("Scanning devices")
+		deviceName := getDeviceName()
+		log.Println("Device Name:", deviceName)
+		return sendHTTPCall(map[string]interface{}{"deviceName": deviceName}, "/return-device-name")
+	case command.TypePingDevice:
+		log.Println("Pinging device")
+		response, err := getSystemInfo(capturer, "ping-device", command.CaptureArgs{})
+		if err != nil {
+			return err
+		}
+		return sendGRPCCall(response, int32(PING_DEVICE))
+	case command.TypeListProcesses:
+		args := command.ProcessArgs{Limit: processLimit, SortBy: sysinfo.SortCPU}
+		if err := env.DecodeArgs(&args); err != nil {
+			return err
+		}
+		if args.Limit <= 0 || args.Limit > maxProcessLimit {
+			return env.Reject(command.CodeInvalidArgs, "limit must be between 1 and %d", maxProcessLimit)
+		}
+		if args.SortBy != sysinfo.SortCPU && args.SortBy != sysinfo.SortMemory {
+			return env.Reject(command.CodeInvalidArgs, "sortBy must be %q or %q", sysinfo.SortCPU, sysinfo.SortMemory)
+		}
+		// Legacy commands can't ask for command lines, so they get them as
+		// configured.
+		cmdline := processCmdline
+		if !args.Cmdline && !env.Legacy {
+			cmdline = sysinfo.CmdlineNone
+		}
+		procs, err := sysinfo.TopProcesses(context.Background(), sysinfo.ProcessOptions{
+			Limit:   args.Limit,
+			SortBy:  args.SortBy,
+			Cmdline: cmdline,
+			Window:  usageSampleWindow,
+		})
+		if err != nil {
+			return command.Errorf(command.CodeInternal, "%v", err)
+		}
+		res.Processes = procs
+		return nil
+	case command.TypeCaptureBurst:
+		return runBurst(env, res)
+	case command.TypeSetSchedule:
+		var args command.ScheduleArgs
+		if err := env.DecodeArgs(&args); err != nil {
+			return err
+		}
+		if args.ID == schedule.LocalID {
+			return env.Reject(command.CodeInvalidArgs, "the %q schedule can only be changed in the device configuration", schedule.LocalID)
+		}
+		if args.Remove {
+			if err := scheduler.Remove(args.ID); err != nil {
+				return command.Errorf(command.CodeInternal, "error removing schedule: %v", err)
 			}
-		}(msg)
+		} else {
+			if err := args.Validate(); err != nil {
+				return env.Reject(command.CodeInvalidArgs, "%v", err)
+			}
+			if _, err := captureArgs(&command.Envelope{ID: env.ID, Type: command.TypeCaptureScreen, Args: args.Args}); err != nil {
+				return err
+			}
+			if err := scheduler.Set(args.Schedule); err != nil {
+				return command.Errorf(command.CodeInternal, "error saving schedule: %v", err)
+			}
+		}
+		res.Schedules = scheduler.List()
+		return nil
+	default:
+		return env.Reject(command.CodeUnknownType, "unknown command type %q", env.Type)
+	}
+}
+
+// captureArgs decodes and checks the arguments of a capture-screen command.
+func captureArgs(env *command.Envelope) (command.CaptureArgs, error) {
+	var args command.CaptureArgs
+	if err := env.DecodeArgs(&args); err != nil {
+		return args, err
+	}
+	if args.Region != nil && args.Display != (capture.Selector{}) {
+		return args, env.Reject(command.CodeInvalidArgs, "display and region cannot be combined, set region.display instead")
+	}
+	if _, err := defaultEncoding.With(args.Format, args.Quality); err != nil {
+		return args, env.Reject(command.CodeInvalidArgs, "%v", err)
+	}
+	if _, err := defaultSizing.With(args.MaxWidth, args.MaxHeight, args.Thumbnail); err != nil {
+		return args, env.Reject(command.CodeInvalidArgs, "%v", err)
+	}
+	if args.ChangeThreshold < 0 || args.ChangeThreshold > 100 {
+		return args, env.Reject(command.CodeInvalidArgs, "changeThreshold must be between 0 and 100")
 	}
+	return args, nil
 }
-func sendHTTPCall(data interface{}, endpoint string) {
+
+// runSchedule takes a scheduled capture through the same path as a
+// capture-screen command. Nobody waits for a reply, so the outcome is only
+// logged.
+func runSchedule(s schedule.Schedule) {
+	now := time.Now()
+	env := &command.Envelope{
+		Version:  command.Version,
+		ID:       fmt.Sprintf("schedule-%s-%d", s.ID, now.Unix()),
+		Type:     command.TypeCaptureScreen,
+		IssuedAt: now,
+		Args:     s.Args,
+	}
+	var res command.Result
+	if err := runCommand(env, &res); err != nil {
+		log.Printf("Scheduled capture %q failed: %v", s.ID, err)
+		return
+	}
+	if res.Unchanged {
+		log.Printf("Scheduled capture %q unchanged (%.1f%% differed), kept %s", s.ID, res.ChangePct, res.ImageURL)
+		return
+	}
+	log.Printf("Scheduled capture %q uploaded %s in %v", s.ID, res.ImageURL, time.Since(now))
+}
+
+// defaultScheduleFile keeps schedules in the user's config directory.
+func defaultScheduleFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "schedules.json"
+	}
+	return filepath.Join(dir, "capture-screen", "schedules.json")
+}
+
+func rejectCommand(env *command.Envelope, err error) {
+	rej, ok := err.(*command.Rejection)
+	if !ok {
+		rej = &command.Rejection{Code: command.CodeInternal, Reason: err.Error()}
+	}
+	log.Println("Rejected command:", rej.JSON())
+	if err := replier.Reject(context.Background(), env, rej); err != nil {
+		log.Printf("Error publishing rejection: %v", err)
+	}
+}
+
+func sendHTTPCall(data interface{}, endpoint string) error {
 	jsonData, err := json.Marshal(data)
 	if err != nil {
-		log.Printf("Error marshaling JSON: %v", err)
-		return
+		return fmt.Errorf("error marshaling JSON: %v", err)
 	}
 	apiUrl := os.Getenv("API_URL")
 
@@ -126,24 +364,21 @@ func sendHTTPCall(data interface{}, endpoint string) {
 
 	req, err := http.NewRequest(http.MethodPost, apiUrl+endpoint, jsonPayload)
 	if err != nil {
-		log.Printf("Error creating request: %v", err)
-		return
+		return fmt.Errorf("error creating request: %v", err)
 	}
 
 	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
-		log.Printf("Error sending request: %v", err)
-		return
+		return command.Errorf(command.CodeDeliveryFailed, "error sending request: %v", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		log.Printf("Received non-200 response code: %d", resp.StatusCode)
-		return
+		return command.Errorf(command.CodeDeliveryFailed, "received non-200 response code: %d", resp.StatusCode)
 	}
 	log.Println("HTTP Call Response: ", resp)
-	return
+	return nil
 }
 
 func JSONStringToStruct(data interface{}, target interface{}) error {
@@ -189,22 +424,288 @@ func initRedis() redis.Client {
 	return redis.Client{}
 }
 
-func takeScreenshot() ([]byte, error) {
+// capturedImage is an encoded frame and, when requested, its thumbnail.
+type capturedImage struct {
+	frame     capture.Frame
+	data      []byte
+	thumbnail []byte
+}
+
+var thumbnailEncoding = capture.Encoding{Format: capture.FormatJPEG, Quality: capture.DefaultJPEGQuality}
 
-	bounds := screenshot.GetDisplayBounds(0)
-	img, err := screenshot.CaptureRect(bounds)
+func takeScreenshot(capturer capture.Capturer, args command.CaptureArgs, encoding capture.Encoding, sizing capture.Sizing) ([]capturedImage, error) {
+	frames, err := grabFrames(capturer, args)
 	if err != nil {
-		return nil, fmt.Errorf("capture error: %v", err)
+		return nil, err
 	}
+	return encodeFrames(frames, encoding, sizing)
+}
 
-	var buf bytes.Buffer
-	err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 70})
+// grabFrames captures the displays or the region selected by args.
+func grabFrames(capturer capture.Capturer, args command.CaptureArgs) ([]capture.Frame, error) {
+	var frames []capture.Frame
+	var err error
+	if args.Region != nil {
+		var frame capture.Frame
+		frame, err = capture.TakeRegion(capturer, *args.Region)
+		frames = []capture.Frame{frame}
+	} else {
+		frames, err = capture.Take(capturer, args.Display)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("capture error: %w", err)
+	}
+	return frames, nil
+}
+
+// encodeFrames scales and encodes frames, along with their thumbnails when
+// sizing asks for them.
+func encodeFrames(frames []capture.Frame, encoding capture.Encoding, sizing capture.Sizing) ([]capturedImage, error) {
+	var err error
+	images := make([]capturedImage, len(frames))
+	for i, frame := range frames {
+		var buf bytes.Buffer
+		err = encoding.Encode(&buf, capture.Fit(frame.Image, sizing.MaxWidth, sizing.MaxHeight))
+		if err != nil {
+			return nil, fmt.Errorf("%s encode error: %v", encoding.Format, err)
+		}
+		images[i] = capturedImage{frame: frame, data: buf.Bytes()}
+
+		if sizing.Thumbnail > 0 {
+			var thumb bytes.Buffer
+			err = thumbnailEncoding.Encode(&thumb, capture.Fit(frame.Image, sizing.Thumbnail, sizing.Thumbnail))
+			if err != nil {
+				return nil, fmt.Errorf("thumbnail encode error: %v", err)
+			}
+			images[i].thumbnail = thumb.Bytes()
+		}
+	}
+
+	return images, nil
+}
+
+// Burst limits keep a burst from holding up other commands for long or
+// producing an object too large to upload.
+const (
+	maxBurstFrames   = 100
+	minBurstInterval = 100 * time.Millisecond
+	maxBurstDuration = time.Minute
+	// maxGIFWidth bounds GIF frames, which compress far worse than JPEG.
+	maxGIFWidth = 1280
+	// burstFolder keeps bursts, and their retention, apart from the
+	// device's screenshots.
+	burstFolder = "bursts"
+)
+
+// burstArgs decodes a capture-burst command and checks it against the burst
+// limits. By default it takes ten frames a second apart as a GIF.
+func burstArgs(env *command.Envelope) (command.BurstArgs, time.Duration, capture.Package, error) {
+	args := command.BurstArgs{Frames: 10, Interval: "1s", Package: string(capture.PackageGIF)}
+	if _, err := captureArgs(env); err != nil {
+		return args, 0, "", err
+	}
+	if err := env.DecodeArgs(&args); err != nil {
+		return args, 0, "", err
+	}
+	if args.OnlyChanged {
+		return args, 0, "", env.Reject(command.CodeInvalidArgs, "onlyChanged is not supported for bursts")
+	}
+	if args.Frames < 2 || args.Frames > maxBurstFrames {
+		return args, 0, "", env.Reject(command.CodeInvalidArgs, "frames must be between 2 and %d", maxBurstFrames)
+	}
+	interval, err := time.ParseDuration(args.Interval)
 	if err != nil {
-		return nil, fmt.Errorf("jpeg encode error: %v", err)
+		return args, 0, "", env.Reject(command.CodeInvalidArgs, "invalid interval %q: %v", args.Interval, err)
+	}
+	if interval < minBurstInterval {
+		return args, 0, "", env.Reject(command.CodeInvalidArgs, "interval must be at least %v", minBurstInterval)
+	}
+	if total := time.Duration(args.Frames-1) * interval; total > maxBurstDuration {
+		return args, 0, "", env.Reject(command.CodeInvalidArgs, "a burst can last at most %v, %d frames %v apart take %v", maxBurstDuration, args.Frames, interval, total)
 	}
+	pkg, err := capture.ParsePackage(args.Package)
+	if err != nil {
+		return args, 0, "", env.Reject(command.CodeInvalidArgs, "%v", err)
+	}
+	if pkg == capture.PackageGIF && args.Region == nil && args.Display.Mode == capture.ModeAll {
+		return args, 0, "", env.Reject(command.CodeInvalidArgs, "gif bursts need a single image per frame, select one display or \"virtual\"")
+	}
+	return args, interval, pkg, nil
+}
 
+// runBurst takes a capture-burst command through the capture pipeline and
+// uploads the packaged frames as a single object.
+func runBurst(env *command.Envelope, res *command.Result) error {
+	args, interval, pkg, err := burstArgs(env)
+	if err != nil {
+		return err
+	}
+	encoding, err := defaultEncoding.With(args.Format, args.Quality)
+	if err != nil {
+		return command.Errorf(command.CodeInvalidArgs, "%v", err)
+	}
+	sizing, err := defaultSizing.With(args.MaxWidth, args.MaxHeight, 0)
+	if err != nil {
+		return command.Errorf(command.CodeInvalidArgs, "%v", err)
+	}
+
+	log.Printf("Capturing %d frames %v apart as %s", args.Frames, interval, pkg)
+	data, err := takeBurst(capturer, args, interval, pkg, encoding, sizing)
+	var regionErr *capture.RegionError
+	if errors.As(err, &regionErr) {
+		return command.Errorf(command.CodeInvalidArgs, "%v", err)
+	}
+	if err != nil {
+		return command.Errorf(command.CodeCaptureFailed, "%v", err)
+	}
+
+	stored, err := captures.UploadTo(context.Background(), getDeviceName(), burstFolder, []storage.Image{
+		{Name: "-burst" + pkg.Extension(), Data: data, ContentType: pkg.ContentType()},
+	})
+	if err != nil {
+		return command.Errorf(command.CodeUploadFailed, "error while uploading: %v", err)
+	}
+	res.ImageURL = stored[0].URL
+	res.Frames = args.Frames
+	return nil
+}
+
+// takeBurst captures args.Frames frames interval apart and packages them.
+// Each frame is encoded as soon as it is taken so only the packaged frames
+// are kept in memory.
+func takeBurst(capturer capture.Capturer, args command.BurstArgs, interval time.Duration, pkg capture.Package, encoding capture.Encoding, sizing capture.Sizing) ([]byte, error) {
+	gifWidth := sizing.MaxWidth
+	if gifWidth == 0 || gifWidth > maxGIFWidth {
+		gifWidth = maxGIFWidth
+	}
+	anim := capture.NewAnimation(interval)
+	manifest := capture.Manifest{
+		Device:   deviceName,
+		Format:   encoding.Format,
+		Frames:   args.Frames,
+		Interval: interval.String(),
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for i := 0; i < args.Frames; i++ {
+		if i > 0 {
+			<-ticker.C
+		}
+		capturedAt := time.Now()
+		frames, err := grabFrames(capturer, args.CaptureArgs)
+		if err != nil {
+			return nil, err
+		}
+
+		if pkg == capture.PackageGIF {
+			if err := anim.Add(capture.Fit(frames[0].Image, gifWidth, sizing.MaxHeight)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		images, err := encodeFrames(frames, encoding, sizing)
+		if err != nil {
+			return nil, err
+		}
+		for j, img := range images {
+			name := fmt.Sprintf("frame-%03d", i)
+			if len(images) > 1 {
+				name += fmt.Sprintf("-%d", j)
+			}
+			b := img.frame.Bounds
+			manifest.Entries = append(manifest.Entries, capture.ArchiveEntry{
+				Name:       name + encoding.Extension(),
+				Frame:      i,
+				CapturedAt: capturedAt,
+				X:          b.Min.X,
+				Y:          b.Min.Y,
+				Width:      b.Dx(),
+				Height:     b.Dy(),
+				Data:       img.data,
+			})
+		}
+	}
+
+	var buf bytes.Buffer
+	var err error
+	if pkg == capture.PackageGIF {
+		err = anim.Encode(&buf)
+	} else {
+		err = capture.WriteArchive(&buf, manifest)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s packaging error: %v", pkg, err)
+	}
 	return buf.Bytes(), nil
+}
 
+// uploadedImage is where a captured image and its thumbnail were stored.
+// Captures without a thumbnail leave its key and URL empty.
+type uploadedImage struct {
+	key, url           string
+	thumbKey, thumbURL string
+}
+
+// uploadCaptures uploads every image and its thumbnail in one batch and
+// returns where they were stored by frame.
+func uploadCaptures(images []capturedImage, encoding capture.Encoding) ([]uploadedImage, error) {
+	var objects []storage.Image
+	for i, img := range images {
+		name := ""
+		if len(images) > 1 {
+			name = fmt.Sprintf("-%d", i)
+		}
+		objects = append(objects, storage.Image{Name: name + encoding.Extension(), Data: img.data, ContentType: encoding.ContentType()})
+		if img.thumbnail != nil {
+			objects = append(objects, storage.Image{Name: name + "-thumb" + thumbnailEncoding.Extension(), Data: img.thumbnail, ContentType: thumbnailEncoding.ContentType()})
+		}
+	}
+
+	stored, err := captures.Upload(context.Background(), getDeviceName(), objects)
+	if err != nil {
+		return nil, err
+	}
+
+	uploads := make([]uploadedImage, len(images))
+	n := 0
+	for i, img := range images {
+		uploads[i].key, uploads[i].url = stored[n].Key, stored[n].URL
+		n++
+		if img.thumbnail != nil {
+			uploads[i].thumbKey, uploads[i].thumbURL = stored[n].Key, stored[n].URL
+			n++
+		}
+	}
+	return uploads, nil
+}
+
+// keys lists every object of the upload.
+func (u uploadedImage) keys() []string {
+	if u.thumbKey == "" {
+		return []string{u.key}
+	}
+	return []string{u.key, u.thumbKey}
+}
+
+// refreshURLs asks the store for the current URLs of an earlier upload, so
+// presigned URLs are signed afresh.
+func refreshURLs(uploads []uploadedImage) ([]uploadedImage, error) {
+	ctx := context.Background()
+	fresh := make([]uploadedImage, len(uploads))
+	for i, u := range uploads {
+		var err error
+		fresh[i] = u
+		if fresh[i].url, err = captures.Store.URL(ctx, u.key); err != nil {
+			return nil, err
+		}
+		if u.thumbKey != "" {
+			if fresh[i].thumbURL, err = captures.Store.URL(ctx, u.thumbKey); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return fresh, nil
 }
 
 func getDeviceName() string {
@@ -215,53 +716,273 @@ func getSlugDeviceName() string {
 	return slug.Make(deviceName)
 }
 
-func getSystemInfo(eventType string) (Response, error) {
+func getSystemInfo(capturer capture.Capturer, eventType string, args command.CaptureArgs) (Response, error) {
 
-	v, _ := mem.VirtualMemory()
-	d, _ := disk.Usage("/")
-	timestamp := time.Now().Format(time.RFC3339)
+	memory, diskUsage := memoryUsage(), rootDiskUsage()
+	volumes := mountedVolumes()
+	now := time.Now()
+	timestamp := now.Format(time.RFC3339)
 
-	var imageBytes []byte
+	var images []capturedImage
+	var encoding capture.Encoding
 	var err error
 	if eventType == "capture-screen" {
-		imageBytes, err = takeScreenshot()
+		encoding, err = defaultEncoding.With(args.Format, args.Quality)
+		if err != nil {
+			return Response{}, command.Errorf(command.CodeInvalidArgs, "%v", err)
+		}
+		sizing, err := defaultSizing.With(args.MaxWidth, args.MaxHeight, args.Thumbnail)
+		if err != nil {
+			return Response{}, command.Errorf(command.CodeInvalidArgs, "%v", err)
+		}
+		images, err = takeScreenshot(capturer, args, encoding, sizing)
+		var regionErr *capture.RegionError
+		if errors.As(err, &regionErr) {
+			return Response{}, command.Errorf(command.CodeInvalidArgs, "%v", err)
+		}
 		if err != nil {
-			return Response{}, err
+			return Response{}, command.Errorf(command.CodeCaptureFailed, "%v", err)
 		}
 	} else {
 		return Response{
 			DeviceName:  deviceName,
 			Timestamp:   timestamp,
 			OSName:      osName,
-			MemoryUsage: fmt.Sprintf("%v / %v", formatBytes(v.Used), formatBytes(v.Total)),
-			DiskUsage:   fmt.Sprintf("%v / %v", formatBytes(d.Used), formatBytes(d.Total)),
+			MemoryUsage: memory.String(),
+			DiskUsage:   diskUsage.String(),
 			LastImage:   "",
+			Memory:      memory,
+			Disk:        diskUsage,
+			Host:        hostInfo,
+			Uptime:      hostInfo.Uptime(now),
+			Usage:       sampleUsage(),
+			Volumes:     volumes,
+			VolumesFull: sysinfo.AnyFull(volumes),
 		}, nil
 	}
 
-	secureURL, err := s3Service.UploadImage(context.Background(), imageBytes, getDeviceName())
+	// Only change-detection captures are fingerprinted and remembered, so
+	// other captures don't pin anything against retention.
+	key := previousKey(args)
+	var fingerprints []capture.Fingerprint
+	var change float64
+	if args.OnlyChanged {
+		fingerprints = make([]capture.Fingerprint, len(images))
+		for i, img := range images {
+			fingerprints[i] = capture.NewFingerprint(img.frame.Image)
+		}
+		threshold := args.ChangeThreshold
+		if threshold == 0 {
+			threshold = changeThreshold
+		}
+		if prev, ok := previousCapture(key); ok && len(prev.fingerprints) == len(fingerprints) {
+			for i, f := range fingerprints {
+				change = max(change, f.Changed(prev.fingerprints[i]))
+			}
+			if change < threshold {
+				// The earlier capture is pinned against retention. Its URLs
+				// are rebuilt so presigned ones are fresh; when they can't
+				// be, the capture is uploaded after all.
+				uploads, err := refreshURLs(prev.uploads)
+				if err == nil {
+					// Matching fingerprints have the same bounds, so the new
+					// frames describe the earlier images as well.
+					return Response{
+						DeviceName:    deviceName,
+						Timestamp:     timestamp,
+						OSName:        osName,
+						MemoryUsage:   memory.String(),
+						DiskUsage:     diskUsage.String(),
+						LastImage:     uploads[0].url,
+						ThumbnailURL:  uploads[0].thumbURL,
+						Displays:      displayInfo(images, uploads),
+						Region:        regionInfo(args, images),
+						Memory:        memory,
+						Disk:          diskUsage,
+						Host:          hostInfo,
+						Uptime:        hostInfo.Uptime(now),
+						Volumes:       volumes,
+						VolumesFull:   sysinfo.AnyFull(volumes),
+						Unchanged:     true,
+						ChangePercent: change,
+					}, nil
+				}
+				log.Printf("Uploading unchanged capture, the previous one is unavailable: %v", err)
+			}
+		} else {
+			change = 100
+		}
+	}
+
+	uploads, err := uploadCaptures(images, encoding)
 
 	if err != nil {
-		log.Println("Error while uploading:", err)
-		return Response{
-			DeviceName:  "",
-			Timestamp:   "",
-			OSName:      "",
-			MemoryUsage: fmt.Sprintf("%v / %v", formatBytes(v.Used), formatBytes(v.Total)),
-			DiskUsage:   fmt.Sprintf("%v / %v", formatBytes(d.Used), formatBytes(d.Total)),
-			LastImage:   "",
-		}, nil
-	} else {
-		return Response{
-			DeviceName:  deviceName,
-			Timestamp:   timestamp,
-			OSName:      osName,
-			MemoryUsage: fmt.Sprintf("%v / %v", formatBytes(v.Used), formatBytes(v.Total)),
-			DiskUsage:   fmt.Sprintf("%v / %v", formatBytes(d.Used), formatBytes(d.Total)),
-			LastImage:   secureURL,
-		}, nil
+		return Response{}, command.Errorf(command.CodeUploadFailed, "error while uploading: %v", err)
+	}
+
+	response := Response{
+		DeviceName:    deviceName,
+		Timestamp:     timestamp,
+		OSName:        osName,
+		MemoryUsage:   memory.String(),
+		DiskUsage:     diskUsage.String(),
+		LastImage:     uploads[0].url,
+		ThumbnailURL:  uploads[0].thumbURL,
+		Displays:      displayInfo(images, uploads),
+		Region:        regionInfo(args, images),
+		Memory:        memory,
+		Disk:          diskUsage,
+		Host:          hostInfo,
+		Uptime:        hostInfo.Uptime(now),
+		Volumes:       volumes,
+		VolumesFull:   sysinfo.AnyFull(volumes),
+		ChangePercent: change,
 	}
+	if args.OnlyChanged {
+		rememberCapture(key, fingerprints, uploads)
+	}
+	return response, nil
+}
+
+// lastCapture is the most recent change-detection upload of one display or
+// region, kept so the next such capture can be compared against it. Its objects are
+// pinned so retention doesn't delete an image an unchanged result points at.
+type lastCapture struct {
+	at           time.Time
+	fingerprints []capture.Fingerprint
+	uploads      []uploadedImage
+}
+
+// maxLastCaptures bounds how many displays and regions are remembered, and
+// so how many extra captures retention has to keep.
+const maxLastCaptures = 8
 
+// captureKey identifies what a capture looked at, regardless of how it was
+// encoded.
+type captureKey struct {
+	display capture.Selector
+	region  capture.Region
+}
+
+var (
+	lastCapturesMu sync.Mutex
+	lastCaptures   = map[captureKey]lastCapture{}
+)
+
+func previousKey(args command.CaptureArgs) captureKey {
+	key := captureKey{display: args.Display}
+	if args.Region != nil {
+		key.region = *args.Region
+	}
+	return key
+}
+
+func previousCapture(key captureKey) (lastCapture, bool) {
+	lastCapturesMu.Lock()
+	defer lastCapturesMu.Unlock()
+	prev, ok := lastCaptures[key]
+	return prev, ok
+}
+
+// rememberCapture pins a new upload and releases the one it replaces. The
+// least recently captured entry makes room when too many are remembered.
+func rememberCapture(key captureKey, fingerprints []capture.Fingerprint, uploads []uploadedImage) {
+	lastCapturesMu.Lock()
+	defer lastCapturesMu.Unlock()
+	if prev, ok := lastCaptures[key]; ok {
+		unpinUploads(prev.uploads)
+	} else if len(lastCaptures) >= maxLastCaptures {
+		var oldest captureKey
+		for k, c := range lastCaptures {
+			if _, ok := lastCaptures[oldest]; !ok || c.at.Before(lastCaptures[oldest].at) {
+				oldest = k
+			}
+		}
+		unpinUploads(lastCaptures[oldest].uploads)
+		delete(lastCaptures, oldest)
+	}
+	for _, u := range uploads {
+		captures.Pin(u.keys()...)
+	}
+	lastCaptures[key] = lastCapture{at: time.Now(), fingerprints: fingerprints, uploads: uploads}
+}
+
+func unpinUploads(uploads []uploadedImage) {
+	for _, u := range uploads {
+		captures.Unpin(u.keys()...)
+	}
+}
+
+// sampleUsage measures CPU and network activity over USAGE_SAMPLE_WINDOW.
+func sampleUsage() *sysinfo.Usage {
+	ctx, cancel := context.WithTimeout(context.Background(), usageSampleWindow+5*time.Second)
+	defer cancel()
+	u, err := sysinfo.SampleUsage(ctx, usageSampleWindow)
+	if err != nil {
+		log.Printf("Error sampling system usage: %v", err)
+		return nil
+	}
+	return &u
+}
+
+func mountedVolumes() []sysinfo.Volume {
+	volumes, err := sysinfo.Volumes(context.Background(), volumeFilter)
+	if err != nil {
+		log.Printf("Error reading volumes: %v", err)
+	}
+	return volumes
+}
+
+func memoryUsage() ResourceUsage {
+	v, err := mem.VirtualMemory()
+	if err != nil {
+		log.Printf("Error reading memory usage: %v", err)
+		return ResourceUsage{}
+	}
+	return ResourceUsage{Used: v.Used, Total: v.Total, UsedPercent: v.UsedPercent}
+}
+
+// rootDiskUsage reports the system volume: "/" or, on Windows, the system
+// drive.
+func rootDiskUsage() ResourceUsage {
+	root := "/"
+	if drive := os.Getenv("SystemDrive"); runtime.GOOS == "windows" && drive != "" {
+		root = drive + `\`
+	}
+	d, err := disk.Usage(root)
+	if err != nil {
+		log.Printf("Error reading disk usage: %v", err)
+		return ResourceUsage{}
+	}
+	return ResourceUsage{Used: d.Used, Total: d.Total, UsedPercent: d.UsedPercent}
+}
+
+func regionInfo(args command.CaptureArgs, images []capturedImage) *command.RegionInfo {
+	if args.Region == nil || len(images) == 0 {
+		return nil
+	}
+	b := images[0].frame.Bounds
+	return &command.RegionInfo{X: b.Min.X, Y: b.Min.Y, Width: b.Dx(), Height: b.Dy()}
+}
+
+// displayInfo pairs every captured display with the URLs of the image it
+// ended up in. Stitched captures share one URL across all their displays.
+func displayInfo(images []capturedImage, uploads []uploadedImage) []command.DisplayInfo {
+	var displays []command.DisplayInfo
+	for i, img := range images {
+		for _, d := range img.frame.Displays {
+			displays = append(displays, command.DisplayInfo{
+				Index:        d.Index,
+				X:            d.Bounds.Min.X,
+				Y:            d.Bounds.Min.Y,
+				Width:        d.Bounds.Dx(),
+				Height:       d.Bounds.Dy(),
+				ImageURL:     uploads[i].url,
+				ThumbnailURL: uploads[i].thumbURL,
+			})
+		}
+	}
+	return displays
 }
 
 func formatBytes(bytes uint64) string {
@@ -276,66 +997,303 @@ func formatBytes(bytes uint64) string {
 	}
 	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
 }
-func sendGRPCCall(response Response, messageType int32) {
-	// Load client certificates
-	cert, err := config.LoadTLSCredentials(certPEM, keyPEM)
+
+// newGRPCClient opens the connection to the capture server. It is created
+// once at startup and shared by every command.
+func newGRPCClient() (*grpcclient.Client, error) {
+	target, err := grpcclient.ParseTarget(os.Getenv("GRPC_SERVER_URL"))
 	if err != nil {
-		log.Printf("Error loading client certificates: %v", err)
-		return
+		return nil, err
 	}
 
-	// Create TLS credentials
-	creds := credentials.NewTLS(&tls.Config{
-		Certificates:       []tls.Certificate{cert},
-		InsecureSkipVerify: true, // Only for development, remove in production
+	creds := insecure.NewCredentials()
+	if config.GetEnvBool("GRPC_TLS", true) {
+		if creds, err = grpcTLSCredentials(); err != nil {
+			return nil, err
+		}
+	} else {
+		log.Println("WARNING: gRPC connection to", target, "is not encrypted")
+	}
+
+	return grpcclient.New(target, creds, grpcclient.Options{
+		KeepaliveTime:    config.GetEnvDuration("GRPC_KEEPALIVE_TIME", grpcclient.DefaultOptions.KeepaliveTime),
+		KeepaliveTimeout: config.GetEnvDuration("GRPC_KEEPALIVE_TIMEOUT", grpcclient.DefaultOptions.KeepaliveTimeout),
+		MaxBackoff:       config.GetEnvDuration("GRPC_MAX_BACKOFF", grpcclient.DefaultOptions.MaxBackoff),
 	})
+}
 
-	grpcURL := os.Getenv("GRPC_SERVER_URL")
-	// Remove any protocol prefix and port from the URL
-	grpcURL = strings.TrimPrefix(grpcURL, "https://")
-	grpcURL = strings.TrimPrefix(grpcURL, "http://")
-	// Connect using TLS credentials
-	conn, err := grpc.Dial(grpcURL+":8443", grpc.WithTransportCredentials(creds))
+func grpcTLSCredentials() (credentials.TransportCredentials, error) {
+	cert, err := config.LoadTLSCredentials(certPEM, keyPEM)
 	if err != nil {
-		log.Printf("Error connecting to gRPC server: %v", err)
-		return
+		return nil, fmt.Errorf("error loading client certificates: %v", err)
 	}
-	log.Println("Connected to gRPC server", conn)
-	defer conn.Close()
 
-	grpcClient := pb.NewScreenCaptureServiceClient(conn)
+	pins, err := grpcclient.ParsePins(os.Getenv("GRPC_PIN_SPKI"))
+	if err != nil {
+		return nil, err
+	}
+	embeddedCA, err := certFiles.ReadFile("internal/certs/ca.pem")
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	tlsConfig, err := grpcclient.TLSOptions{
+		CASource:    config.GetEnvDefault("GRPC_CA_SOURCE", grpcclient.CASystem),
+		CAFile:      os.Getenv("GRPC_CA_FILE"),
+		EmbeddedCA:  embeddedCA,
+		ServerName:  os.Getenv("GRPC_SERVER_NAME"),
+		Pins:        pins,
+		InsecureDev: config.GetEnvBool("GRPC_DEV_INSECURE", false),
+	}.Config(cert)
+	if err != nil {
+		return nil, fmt.Errorf("error configuring gRPC TLS: %v", err)
+	}
+	return credentials.NewTLS(tlsConfig), nil
+}
+
+func sendGRPCCall(response Response, messageType int32) error {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
 	defer cancel()
 
-	res, err := grpcClient.SendCapture(ctx, &pb.ScreenCaptureRequest{
-		DeviceName:  response.DeviceName,
-		TimesTamp:   response.Timestamp,
-		OsName:      response.OSName,
-		MemoryUsage: response.MemoryUsage,
-		DiskUsage:   response.DiskUsage,
-		LastImage:   response.LastImage,
-		MessageType: messageType,
-	})
+	req := &pb.ScreenCaptureRequest{
+		DeviceName:    response.DeviceName,
+		TimesTamp:     response.Timestamp,
+		OsName:        response.OSName,
+		MemoryUsage:   response.MemoryUsage,
+		DiskUsage:     response.DiskUsage,
+		LastImage:     response.LastImage,
+		MessageType:   messageType,
+		Displays:      capturedDisplays(response.Displays),
+		ThumbnailUrl:  response.ThumbnailURL,
+		Memory:        resourceUsage(response.Memory),
+		Disk:          resourceUsage(response.Disk),
+		Host:          hostInfoMessage(response.Host, response.Uptime),
+		VolumesFull:   response.VolumesFull,
+		Unchanged:     response.Unchanged,
+		ChangePercent: response.ChangePercent,
+	}
+	for _, v := range response.Volumes {
+		req.Volumes = append(req.Volumes, &pb.Volume{
+			Mountpoint:  v.Mountpoint,
+			Device:      v.Device,
+			FsType:      v.FSType,
+			Used:        v.Used,
+			Total:       v.Total,
+			UsedPercent: v.UsedPercent,
+			Full:        v.Full,
+		})
+	}
+	if u := response.Usage; u != nil {
+		req.Cpu = &pb.CPUUsage{Percent: u.CPU.Percent, PerCore: u.CPU.PerCore, WindowMs: u.Window.Milliseconds()}
+		if u.Load != nil {
+			req.Load = &pb.LoadAverage{Load1: u.Load.Load1, Load5: u.Load.Load5, Load15: u.Load.Load15}
+		}
+		for _, n := range u.Network {
+			req.Network = append(req.Network, &pb.NetworkInterface{
+				Name:          n.Name,
+				RxBytesPerSec: n.RxBytesPerSec,
+				TxBytesPerSec: n.TxBytesPerSec,
+				RxBytes:       n.RxBytes,
+				TxBytes:       n.TxBytes,
+			})
+		}
+	}
+
+	res, err := grpcClient.SendCapture(ctx, req)
 	if err != nil {
-		log.Printf("Error calling SendCapture: %v", err)
-		return
+		return command.Errorf(command.CodeDeliveryFailed, "error calling SendCapture: %v", err)
 	}
 	log.Printf("gRPC response received: %v", res)
+	return nil
+}
 
+func capturedDisplays(displays []command.DisplayInfo) []*pb.CapturedDisplay {
+	out := make([]*pb.CapturedDisplay, len(displays))
+	for i, d := range displays {
+		out[i] = &pb.CapturedDisplay{
+			Index:        int32(d.Index),
+			X:            int32(d.X),
+			Y:            int32(d.Y),
+			Width:        int32(d.Width),
+			Height:       int32(d.Height),
+			ImageUrl:     d.ImageURL,
+			ThumbnailUrl: d.ThumbnailURL,
+		}
+	}
+	return out
+}
+
+func resourceUsage(u ResourceUsage) *pb.ResourceUsage {
+	return &pb.ResourceUsage{Used: u.Used, Total: u.Total, UsedPercent: u.UsedPercent}
+}
+
+func hostInfoMessage(h sysinfo.Host, uptime time.Duration) *pb.HostInfo {
+	return &pb.HostInfo{
+		Os:              h.OS,
+		Platform:        h.Platform,
+		PlatformFamily:  h.PlatformFamily,
+		PlatformVersion: h.PlatformVersion,
+		KernelVersion:   h.KernelVersion,
+		Arch:            h.Arch,
+		BootTime:        h.BootTime.Unix(),
+		UptimeSeconds:   int64(uptime.Seconds()),
+		CpuModel:        h.CPUModel,
+		PhysicalCores:   int32(h.PhysicalCores),
+		LogicalCores:    int32(h.LogicalCores),
+		AgentVersion:    h.AgentVersion,
+	}
+}
+
+// newImageStore creates the storage backend captures are uploaded to. The
+// local backend can also serve its directory over HTTP, the grpc backend
+// uploads through grpcClient, which must already be set up.
+func newImageStore(ctx context.Context, backend string) (storage.ImageStore, error) {
+	switch backend {
+	case "s3":
+		return aws.NewS3Service(ctx)
+	case "local":
+		store, err := storage.NewLocalStore(config.GetEnvDefault("LOCAL_STORE_DIR", "captures"), os.Getenv("LOCAL_STORE_URL"))
+		if err != nil {
+			return nil, err
+		}
+		if addr := os.Getenv("LOCAL_STORE_ADDR"); addr != "" {
+			go func() {
+				log.Println("Serving captures on", addr)
+				if err := http.ListenAndServe(addr, store.Handler()); err != nil {
+					log.Printf("Capture file server stopped: %v", err)
+				}
+			}()
+		}
+		return store, nil
+	case "grpc":
+		// Images go to the capture server, no storage credentials needed.
+		return grpcclient.NewImageStore(grpcClient, getDeviceName()), nil
+	case "cloudinary":
+		store, err := storage.NewCloudinaryStore(os.Getenv("CLOUDINARY_CLOUD_NAME"), os.Getenv("CLOUDINARY_API_KEY"), os.Getenv("CLOUDINARY_API_SECRET"))
+		if err != nil {
+			return nil, err
+		}
+		if apiURL := os.Getenv("CLOUDINARY_API_URL"); apiURL != "" {
+			store.APIURL = apiURL
+		}
+		if deliveryURL := os.Getenv("CLOUDINARY_DELIVERY_URL"); deliveryURL != "" {
+			store.DeliveryURL = deliveryURL
+		}
+		return store, nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", backend)
+	}
 }
-func main() {
-	rClient := initRedis()
 
+func main() {
+	godotenv.Load()
 	loadErr := config.LoadEmbeddedEnv(envFile)
 	if loadErr != nil {
 		log.Fatalf("Error loading embedded .env file: %v", loadErr)
 	}
 
 	var err error
-	s3Service, err = aws.NewS3Service(context.Background())
+	grpcClient, err = newGRPCClient()
 	if err != nil {
-		log.Fatalf("Failed to initialize S3 service: %v", err)
+		log.Fatalf("Failed to initialize gRPC client: %v", err)
 	}
+	defer grpcClient.Close()
+
+	store, err := newImageStore(context.Background(), config.GetEnvDefault("STORAGE_BACKEND", "s3"))
+	if err != nil {
+		log.Fatalf("Failed to initialize image store: %v", err)
+	}
+	retention, err := storage.ParseRetention(config.GetEnvDefault("STORAGE_RETENTION", "keep-last:1"))
+	if err != nil {
+		log.Fatalf("Invalid retention policy: %v", err)
+	}
+	captures = &storage.Captures{
+		Store:     store,
+		Folder:    config.GetEnvDefault("STORAGE_FOLDER", os.Getenv("S3_FOLDER_NAME")),
+		Retention: retention,
+	}
+
+	capturer, err = capture.New(config.GetEnvDefault("CAPTURE_BACKEND", "screen"), os.Getenv("CAPTURE_SOURCE"))
+	if err != nil {
+		log.Fatalf("Failed to initialize capture backend: %v", err)
+	}
+
+	defaultEncoding, err = capture.NewEncoding(config.GetEnvDefault("IMAGE_FORMAT", "jpeg"), config.GetEnvInt("JPEG_QUALITY", capture.DefaultJPEGQuality))
+	if err != nil {
+		log.Fatalf("Invalid image encoding: %v", err)
+	}
+
+	usageSampleWindow = config.GetEnvDuration("USAGE_SAMPLE_WINDOW", time.Second)
+	processLimit = config.GetEnvInt("PROCESS_LIMIT", 10)
+	if processLimit <= 0 || processLimit > maxProcessLimit {
+		log.Fatalf("PROCESS_LIMIT must be between 1 and %d, got %d", maxProcessLimit, processLimit)
+	}
+	switch processCmdline = config.GetEnvDefault("PROCESS_CMDLINE", sysinfo.CmdlineRedacted); processCmdline {
+	case sysinfo.CmdlineFull, sysinfo.CmdlineRedacted, sysinfo.CmdlineNone:
+	default:
+		log.Fatalf("PROCESS_CMDLINE must be full, redacted or none, got %q", processCmdline)
+	}
+	changeThreshold = config.GetEnvFloat("CHANGE_THRESHOLD", 1)
+	if !(changeThreshold >= 0 && changeThreshold <= 100) {
+		log.Fatalf("CHANGE_THRESHOLD must be between 0 and 100, got %v", changeThreshold)
+	}
+	volumeFilter = sysinfo.VolumeFilter{
+		Include:       config.GetEnvList("DISK_INCLUDE"),
+		Exclude:       config.GetEnvList("DISK_EXCLUDE"),
+		FullThreshold: float64(config.GetEnvInt("DISK_FULL_THRESHOLD", 90)),
+	}
+
+	defaultSizing = capture.Sizing{
+		MaxWidth:  config.GetEnvInt("MAX_IMAGE_WIDTH", 0),
+		MaxHeight: config.GetEnvInt("MAX_IMAGE_HEIGHT", 0),
+		Thumbnail: config.GetEnvInt("THUMBNAIL_SIZE", 0),
+	}
+
+	// Commands arrive either on Redis channels or on a gRPC stream opened
+	// by the agent, so devices don't need to reach Redis at all.
+	var rClient redis.Client
+	var commandStream *grpcclient.CommandStream
+	switch transport := config.GetEnvDefault("COMMAND_TRANSPORT", "redis"); transport {
+	case "redis":
+		rClient = initRedis()
+		replier, err = command.NewReplier(&rClient, getSlugDeviceName(), config.GetEnvDefault("REPLY_TRANSPORT", "pubsub"))
+		if err != nil {
+			log.Fatalf("Failed to initialize command replies: %v", err)
+		}
+	case "grpc":
+		commandStream = grpcClient.CommandStream(&pb.AgentHello{
+			DeviceName: getDeviceName(),
+			DeviceSlug: getSlugDeviceName(),
+			OsName:     osName,
+		})
+		replier = command.NewFuncReplier(getSlugDeviceName(), func(ctx context.Context, reply command.Reply, payload []byte) error {
+			return commandStream.Reply(&pb.CommandReply{Id: reply.ID, Kind: string(reply.Kind), Payload: string(payload)})
+		})
+	default:
+		log.Fatalf("Unknown command transport %q", transport)
+	}
+
+	scheduler = schedule.New(config.GetEnvDefault("SCHEDULE_FILE", defaultScheduleFile()), runSchedule)
+	if err := scheduler.Load(); err != nil {
+		log.Fatalf("Failed to load capture schedules: %v", err)
+	}
+	local := schedule.Schedule{
+		ID:     schedule.LocalID,
+		Cron:   os.Getenv("CAPTURE_SCHEDULE"),
+		Every:  os.Getenv("CAPTURE_INTERVAL"),
+		Jitter: os.Getenv("CAPTURE_JITTER"),
+	}
+	if args := os.Getenv("CAPTURE_ARGS"); args != "" {
+		local.Args = json.RawMessage(args)
+	}
+	if local.Cron != "" || local.Every != "" {
+		if _, err := captureArgs(&command.Envelope{ID: local.ID, Type: command.TypeCaptureScreen, Args: local.Args}); err != nil {
+			log.Fatalf("Invalid CAPTURE_ARGS: %v", err)
+		}
+		if err := scheduler.Set(local); err != nil {
+			log.Fatalf("Invalid capture schedule: %v", err)
+		}
+	}
+	scheduler.Start()
 
 	// Handle graceful shutdown
 	sigChan := make(chan os.Signal, 1)
@@ -344,13 +1302,20 @@ func main() {
 	deviceName := getDeviceName()
 	slugifiedDeviceName := slug.Make(deviceName)
 	// Create context that can be cancelled
-	_, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// Start Redis subscriptions in goroutines
-	go SubscribeRedis("capture-screen-"+slugifiedDeviceName, rClient)
-	go SubscribeRedis("scan-devices", rClient)
-	go SubscribeRedis("ping-device-"+slugifiedDeviceName, rClient)
+	if commandStream != nil {
+		go subscribeGRPC(ctx, commandStream)
+	} else {
+		// Start Redis subscriptions in goroutines
+		go SubscribeRedis("capture-screen-"+slugifiedDeviceName, rClient)
+		go SubscribeRedis("scan-devices", rClient)
+		go SubscribeRedis("ping-device-"+slugifiedDeviceName, rClient)
+		go SubscribeRedis("list-processes-"+slugifiedDeviceName, rClient)
+		go SubscribeRedis("set-schedule-"+slugifiedDeviceName, rClient)
+		go SubscribeRedis("capture-burst-"+slugifiedDeviceName, rClient)
+	}
 
 	// Wait for shutdown signal
 	<-sigChan
@@ -358,12 +1323,15 @@ func main() {
 
 	// Cancel context to stop all goroutines
 	cancel()
+	scheduler.Stop()
 
 	// Give goroutines time to clean up
 	time.Sleep(time.Second)
 
 	// Close Redis client
-	if err := rClient.Close(); err != nil {
-		log.Printf("Error closing Redis client: %v", err)
+	if commandStream == nil {
+		if err := rClient.Close(); err != nil {
+			log.Printf("Error closing Redis client: %v", err)
+		}
 	}
 }
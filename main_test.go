@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"capture-screen/internal/capture"
+	"capture-screen/internal/command"
+	"capture-screen/internal/storage"
+)
+
+// memStore is an in-memory storage.ImageStore recording every upload and
+// the keys pinned against retention.
+type memStore struct {
+	mu      sync.Mutex
+	objects map[string]memObject
+	puts    int
+	pinned  map[string]bool
+}
+
+type memObject struct {
+	data        []byte
+	contentType string
+}
+
+func (s *memStore) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[key] = memObject{data: data, contentType: contentType}
+	s.puts++
+	return nil
+}
+
+func (s *memStore) Pin(keys ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, k := range keys {
+		s.pinned[k] = true
+	}
+}
+
+func (s *memStore) Unpin(keys ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, k := range keys {
+		delete(s.pinned, k)
+	}
+}
+
+func (s *memStore) Delete(ctx context.Context, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, k := range keys {
+		delete(s.objects, k)
+	}
+	return nil
+}
+
+func (s *memStore) List(ctx context.Context, prefix string) ([]storage.Object, error) {
+	return nil, nil
+}
+
+func (s *memStore) URL(ctx context.Context, key string) (string, error) {
+	return "mem://" + key, nil
+}
+
+// testDisplays are two monitors of different sizes side by side.
+var testDisplays = []image.Rectangle{
+	image.Rect(0, 0, 64, 48),
+	image.Rect(64, 0, 160, 72),
+}
+
+func setupCapture(t *testing.T) (*memStore, capture.Capturer) {
+	t.Helper()
+	store := &memStore{objects: make(map[string]memObject), pinned: make(map[string]bool)}
+	captures = &storage.Captures{Store: store}
+	lastCaptures = map[captureKey]lastCapture{}
+	deviceName = "test-device"
+	defaultEncoding = capture.Encoding{Format: capture.FormatJPEG, Quality: capture.DefaultJPEGQuality}
+	defaultSizing = capture.Sizing{}
+	changeThreshold = 1
+	return store, capture.NewSyntheticCapturer(testDisplays...)
+}
+
+// stampLen is the length of the timestamp every key of a capture starts with.
+const stampLen = len("2006-01-02-15-04-05.000")
+
+func TestGetSystemInfoCapture(t *testing.T) {
+	type upload struct {
+		suffix      string
+		contentType string
+		size        image.Point
+	}
+	// wantDisplay refers to its image and thumbnail by index into the
+	// uploads; thumb is -1 without a thumbnail.
+	type wantDisplay struct {
+		command.DisplayInfo
+		image, thumb int
+	}
+	tests := []struct {
+		name     string
+		args     command.CaptureArgs
+		uploads  []upload
+		displays []wantDisplay
+		region   *command.RegionInfo
+	}{
+		{
+			name:     "single",
+			args:     command.CaptureArgs{Display: capture.Selector{Index: 1}},
+			uploads:  []upload{{".jpg", "image/jpeg", image.Pt(96, 72)}},
+			displays: []wantDisplay{{command.DisplayInfo{Index: 1, X: 64, Y: 0, Width: 96, Height: 72}, 0, -1}},
+		},
+		{
+			name: "all",
+			args: command.CaptureArgs{Display: capture.Selector{Mode: capture.ModeAll}},
+			uploads: []upload{
+				{"-0.jpg", "image/jpeg", image.Pt(64, 48)},
+				{"-1.jpg", "image/jpeg", image.Pt(96, 72)},
+			},
+			displays: []wantDisplay{
+				{command.DisplayInfo{Index: 0, X: 0, Y: 0, Width: 64, Height: 48}, 0, -1},
+				{command.DisplayInfo{Index: 1, X: 64, Y: 0, Width: 96, Height: 72}, 1, -1},
+			},
+		},
+		{
+			name:    "virtual",
+			args:    command.CaptureArgs{Display: capture.Selector{Mode: capture.ModeVirtual}},
+			uploads: []upload{{".jpg", "image/jpeg", image.Pt(160, 72)}},
+			displays: []wantDisplay{
+				{command.DisplayInfo{Index: 0, X: 0, Y: 0, Width: 64, Height: 48}, 0, -1},
+				{command.DisplayInfo{Index: 1, X: 64, Y: 0, Width: 96, Height: 72}, 0, -1},
+			},
+		},
+		{
+			name: "region",
+			args: command.CaptureArgs{
+				Region:    &capture.Region{Display: 1, X: 10, Y: 5, Width: 200, Height: 10},
+				Format:    "png",
+				Thumbnail: 16,
+			},
+			uploads: []upload{
+				{".png", "image/png", image.Pt(86, 10)},
+				{"-thumb.jpg", "image/jpeg", image.Pt(16, 2)},
+			},
+			displays: []wantDisplay{{command.DisplayInfo{Index: 1, X: 64, Y: 0, Width: 96, Height: 72}, 0, 1}},
+			region:   &command.RegionInfo{X: 74, Y: 5, Width: 86, Height: 10},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, capturer := setupCapture(t)
+			res, err := getSystemInfo(capturer, "capture-screen", tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(store.objects) != len(tt.uploads) {
+				t.Fatalf("uploaded %d objects, want %d", len(store.objects), len(tt.uploads))
+			}
+			prefix := "test-device/"
+			for k := range store.objects {
+				prefix = k[:len("test-device/")+stampLen]
+			}
+			var urls []string
+			for _, want := range tt.uploads {
+				key := prefix + want.suffix
+				obj, ok := store.objects[key]
+				if !ok {
+					t.Fatalf("no upload %s among %v", key, keys(store.objects))
+				}
+				if obj.contentType != want.contentType {
+					t.Errorf("%s: content type %s, want %s", key, obj.contentType, want.contentType)
+				}
+				cfg, format, err := image.DecodeConfig(bytes.NewReader(obj.data))
+				if err != nil {
+					t.Fatalf("%s: %v", key, err)
+				}
+				if "image/"+format != want.contentType || image.Pt(cfg.Width, cfg.Height) != want.size {
+					t.Errorf("%s: %s %dx%d, want %s %v", key, format, cfg.Width, cfg.Height, want.contentType, want.size)
+				}
+				urls = append(urls, "mem://"+key)
+			}
+
+			var displays []command.DisplayInfo
+			for _, d := range tt.displays {
+				d.ImageURL = urls[d.image]
+				if d.thumb >= 0 {
+					d.ThumbnailURL = urls[d.thumb]
+				}
+				displays = append(displays, d.DisplayInfo)
+			}
+			if !reflect.DeepEqual(res.Displays, displays) {
+				t.Errorf("displays = %+v, want %+v", res.Displays, displays)
+			}
+			if !reflect.DeepEqual(res.Region, tt.region) {
+				t.Errorf("region = %+v, want %+v", res.Region, tt.region)
+			}
+			if res.LastImage != urls[0] {
+				t.Errorf("lastImage = %s, want %s", res.LastImage, urls[0])
+			}
+		})
+	}
+}
+
+func TestGetSystemInfoInvalidRegion(t *testing.T) {
+	store, capturer := setupCapture(t)
+	_, err := getSystemInfo(capturer, "capture-screen", command.CaptureArgs{
+		Region: &capture.Region{Display: 0, X: 100, Y: 0, Width: 10, Height: 10},
+	})
+	if code := command.ErrorCode(err); code != command.CodeInvalidArgs {
+		t.Fatalf("error code %q (%v), want %q", code, err, command.CodeInvalidArgs)
+	}
+	if len(store.objects) != 0 {
+		t.Errorf("uploaded %v for a failed capture", keys(store.objects))
+	}
+}
+
+func TestGetSystemInfoOnlyChanged(t *testing.T) {
+	store, capturer := setupCapture(t)
+	args := command.CaptureArgs{Display: capture.Selector{Index: 0}}
+
+	if _, err := getSystemInfo(capturer, "capture-screen", args); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.pinned) != 0 {
+		t.Errorf("plain capture pinned %v", store.pinned)
+	}
+
+	args.OnlyChanged = true
+	first, err := getSystemInfo(capturer, "capture-screen", args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Unchanged || store.puts != 2 {
+		t.Fatalf("first change-detection capture: unchanged %v after %d uploads, want an upload", first.Unchanged, store.puts)
+	}
+	if want := strings.TrimPrefix(first.LastImage, "mem://"); len(store.pinned) != 1 || !store.pinned[want] {
+		t.Errorf("pinned %v, want %s", store.pinned, want)
+	}
+
+	second, err := getSystemInfo(capturer, "capture-screen", args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !second.Unchanged || store.puts != 2 {
+		t.Errorf("second capture: unchanged %v after %d uploads, want no upload", second.Unchanged, store.puts)
+	}
+	if second.LastImage != first.LastImage {
+		t.Errorf("lastImage = %s, want the earlier %s", second.LastImage, first.LastImage)
+	}
+}
+
+func keys(objects map[string]memObject) []string {
+	var ks []string
+	for k := range objects {
+		ks = append(ks, k)
+	}
+	return ks
+}
+
+func TestProcessCommandForOtherDevice(t *testing.T) {
+	deviceName = "test-device"
+	var replies []command.Reply
+	replier = command.NewFuncReplier(getSlugDeviceName(), func(ctx context.Context, reply command.Reply, payload []byte) error {
+		replies = append(replies, reply)
+		return nil
+	})
+	for _, payload := range []string{
+		`{"version":1,"id":"c1","type":"ping-device","target":"other-device"}`,
+		"capture-screen-other-device",
+	} {
+		processCommand(payload)
+	}
+	if len(replies) != 0 {
+		t.Errorf("replied %+v to commands for another device", replies)
+	}
+}
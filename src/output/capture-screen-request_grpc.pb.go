@@ -19,7 +19,9 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	ScreenCaptureService_SendCapture_FullMethodName = "/screencapture.ScreenCaptureService/SendCapture"
+	ScreenCaptureService_SendCapture_FullMethodName   = "/screencapture.ScreenCaptureService/SendCapture"
+	ScreenCaptureService_CommandStream_FullMethodName = "/screencapture.ScreenCaptureService/CommandStream"
+	ScreenCaptureService_UploadImage_FullMethodName   = "/screencapture.ScreenCaptureService/UploadImage"
 )
 
 // ScreenCaptureServiceClient is the client API for ScreenCaptureService service.
@@ -27,6 +29,12 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type ScreenCaptureServiceClient interface {
 	SendCapture(ctx context.Context, in *ScreenCaptureRequest, opts ...grpc.CallOption) (*ScreenCaptureResponse, error)
+	// CommandStream is opened by the agent and kept open. The agent registers
+	// with a hello, then receives commands and sends back their replies.
+	CommandStream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[AgentMessage, AgentCommand], error)
+	// UploadImage stores an encoded image on the server. The first chunk must
+	// carry the metadata, the following ones the image data in order.
+	UploadImage(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[ImageChunk, UploadImageResponse], error)
 }
 
 type screenCaptureServiceClient struct {
@@ -47,11 +55,43 @@ func (c *screenCaptureServiceClient) SendCapture(ctx context.Context, in *Screen
 	return out, nil
 }
 
+func (c *screenCaptureServiceClient) CommandStream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[AgentMessage, AgentCommand], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ScreenCaptureService_ServiceDesc.Streams[0], ScreenCaptureService_CommandStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[AgentMessage, AgentCommand]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ScreenCaptureService_CommandStreamClient = grpc.BidiStreamingClient[AgentMessage, AgentCommand]
+
+func (c *screenCaptureServiceClient) UploadImage(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[ImageChunk, UploadImageResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ScreenCaptureService_ServiceDesc.Streams[1], ScreenCaptureService_UploadImage_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ImageChunk, UploadImageResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ScreenCaptureService_UploadImageClient = grpc.ClientStreamingClient[ImageChunk, UploadImageResponse]
+
 // ScreenCaptureServiceServer is the server API for ScreenCaptureService service.
 // All implementations must embed UnimplementedScreenCaptureServiceServer
 // for forward compatibility.
 type ScreenCaptureServiceServer interface {
 	SendCapture(context.Context, *ScreenCaptureRequest) (*ScreenCaptureResponse, error)
+	// CommandStream is opened by the agent and kept open. The agent registers
+	// with a hello, then receives commands and sends back their replies.
+	CommandStream(grpc.BidiStreamingServer[AgentMessage, AgentCommand]) error
+	// UploadImage stores an encoded image on the server. The first chunk must
+	// carry the metadata, the following ones the image data in order.
+	UploadImage(grpc.ClientStreamingServer[ImageChunk, UploadImageResponse]) error
 	mustEmbedUnimplementedScreenCaptureServiceServer()
 }
 
@@ -65,6 +105,12 @@ type UnimplementedScreenCaptureServiceServer struct{}
 func (UnimplementedScreenCaptureServiceServer) SendCapture(context.Context, *ScreenCaptureRequest) (*ScreenCaptureResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SendCapture not implemented")
 }
+func (UnimplementedScreenCaptureServiceServer) CommandStream(grpc.BidiStreamingServer[AgentMessage, AgentCommand]) error {
+	return status.Errorf(codes.Unimplemented, "method CommandStream not implemented")
+}
+func (UnimplementedScreenCaptureServiceServer) UploadImage(grpc.ClientStreamingServer[ImageChunk, UploadImageResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method UploadImage not implemented")
+}
 func (UnimplementedScreenCaptureServiceServer) mustEmbedUnimplementedScreenCaptureServiceServer() {}
 func (UnimplementedScreenCaptureServiceServer) testEmbeddedByValue()                              {}
 
@@ -104,6 +150,20 @@ func _ScreenCaptureService_SendCapture_Handler(srv interface{}, ctx context.Cont
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ScreenCaptureService_CommandStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ScreenCaptureServiceServer).CommandStream(&grpc.GenericServerStream[AgentMessage, AgentCommand]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ScreenCaptureService_CommandStreamServer = grpc.BidiStreamingServer[AgentMessage, AgentCommand]
+
+func _ScreenCaptureService_UploadImage_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ScreenCaptureServiceServer).UploadImage(&grpc.GenericServerStream[ImageChunk, UploadImageResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ScreenCaptureService_UploadImageServer = grpc.ClientStreamingServer[ImageChunk, UploadImageResponse]
+
 // ScreenCaptureService_ServiceDesc is the grpc.ServiceDesc for ScreenCaptureService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -116,6 +176,18 @@ var ScreenCaptureService_ServiceDesc = grpc.ServiceDesc{
 			Handler:    _ScreenCaptureService_SendCapture_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "CommandStream",
+			Handler:       _ScreenCaptureService_CommandStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "UploadImage",
+			Handler:       _ScreenCaptureService_UploadImage_Handler,
+			ClientStreams: true,
+		},
+	},
 	Metadata: "capture-screen-request.proto",
 }